@@ -5,25 +5,94 @@
 // @ts-ignore: Unused imports
 import {Call as $Call} from "@wailsio/runtime";
 
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as executor$0 from "./executor/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as recorder$0 from "./recorder/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as runtime$0 from "./runtime/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as storage$0 from "./storage/models.js";
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore: Unused imports
 import * as types$0 from "./types/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as utils$0 from "./utils/models.js";
+
+/**
+ * ApplyBackupSettings передает хранилищу лимиты снимков из настроек
+ */
+export function ApplyBackupSettings(settings: types$0.Settings): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(116201676, settings) as any;
+    return $resultPromise;
+}
+
+/**
+ * EmitStartupWarnings показывает фронту проблемы, найденные при запуске,
+ * например что data.json был битый и данные подняты из резервной копии
+ */
+export function EmitStartupWarnings(): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(4139655446) as any;
+    return $resultPromise;
+}
+
+/**
+ * GetRunningMacros список запущенных сейчас макросов
+ */
+export function GetRunningMacros(): Promise<runtime$0.Instance[] | null> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(4289088707) as any;
+    return $resultPromise;
+}
 
 export function GetVersion(): Promise<string> & { cancel(): void } {
     let $resultPromise = $Call.ByID(3718814993) as any;
     return $resultPromise;
 }
 
-export function GetWindowList(): Promise<any[] | null> & { cancel(): void } {
+export function GetWindowList(): Promise<utils$0.WindowInfo[] | null> & { cancel(): void } {
     let $resultPromise = $Call.ByID(2414998679) as any;
     return $resultPromise;
 }
 
+export function ListBackups(): Promise<storage$0.BackupInfo[] | null> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(3894161560) as any;
+    return $resultPromise;
+}
+
+/**
+ * MeasureTiming замеряет, насколько точно на этой машине выдерживаются паузы
+ */
+export function MeasureTiming(delay: number, count: number): Promise<executor$0.TimingReport> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(3886936161, delay, count) as any;
+    return $resultPromise;
+}
+
+/**
+ * ProcessRecording чистит записанные действия перед сохранением в макрос
+ */
+export function ProcessRecording(actions: types$0.MacroAction[] | null, opts: recorder$0.Options): Promise<types$0.MacroAction[] | null> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(2700165233, actions, opts) as any;
+    return $resultPromise;
+}
+
 export function ReadAppData(): Promise<types$0.AppData> & { cancel(): void } {
     let $resultPromise = $Call.ByID(3580827948) as any;
     return $resultPromise;
 }
 
+/**
+ * RestoreBackup откатывает data.json к снимку с именем из ListBackups
+ */
+export function RestoreBackup(name: string): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(2346017643, name) as any;
+    return $resultPromise;
+}
+
 export function SetupHotkeys(): Promise<void> & { cancel(): void } {
     let $resultPromise = $Call.ByID(1876575561) as any;
     return $resultPromise;
@@ -34,11 +103,49 @@ export function StartCapture(): Promise<void> & { cancel(): void } {
     return $resultPromise;
 }
 
+/**
+ * StartRecording начинает запись всего ввода, макросы на это время не срабатывают
+ */
+export function StartRecording(): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(3183757554) as any;
+    return $resultPromise;
+}
+
+/**
+ * StopAllMacros аварийно останавливает все макросы, включая одиночные
+ * последовательности, и отпускает все, что они оставили нажатым
+ */
+export function StopAllMacros(): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(1300793361) as any;
+    return $resultPromise;
+}
+
 export function StopCapture(): Promise<void> & { cancel(): void } {
     let $resultPromise = $Call.ByID(3208278569) as any;
     return $resultPromise;
 }
 
+/**
+ * StopRecording завершает запись и возвращает действия с реальными задержками
+ */
+export function StopRecording(): Promise<types$0.MacroAction[] | null> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(1791885700) as any;
+    return $resultPromise;
+}
+
+/**
+ * WatchStorage подхватывает правки data.json, сделанные в обход приложения.
+ * Новые данные фронт получает тем же app_data_changed, что и после своих
+ * записей, об ошибках узнает из storage_warning.
+ */
+export function WatchStorage(): Promise<void> & { cancel(): void } {
+    let $resultPromise = $Call.ByID(486495985) as any;
+    return $resultPromise;
+}
+
+/**
+ * WriteAppData сохраняет данные, при ошибке записи они остаются прежними
+ */
 export function WriteAppData(data: types$0.AppData): Promise<void> & { cancel(): void } {
     let $resultPromise = $Call.ByID(3113756703, data) as any;
     return $resultPromise;
@@ -0,0 +1,35 @@
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as types$0 from "../types/models.js";
+
+export interface Options {
+    /**
+     * DropKeys хоткей остановки записи, выкидывается его последнее нажатие
+     */
+    "drop_keys": types$0.Combo;
+
+    /**
+     * MergeTaps склеивает соседние нажатие и отпускание одной клавиши
+     */
+    "merge_taps": boolean;
+
+    /**
+     * TrimIdle убирает паузы в начале и в конце записи
+     */
+    "trim_idle": boolean;
+
+    /**
+     * Quantize округляет задержки до кратных этому числу мс, 0 выключено
+     */
+    "quantize": number;
+    "min_delay": number;
+
+    /**
+     * MaxDelay 0 значит без ограничения
+     */
+    "max_delay": number;
+}
@@ -0,0 +1,20 @@
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as types$0 from "../types/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as time$0 from "../../../time/models.js";
+
+/**
+ * Instance запущенный экземпляр макроса
+ */
+export interface Instance {
+    "id": number;
+    "macro_id": string;
+    "type": types$0.MacroType;
+    "started_at": time$0.Time;
+}
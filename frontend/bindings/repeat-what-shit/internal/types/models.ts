@@ -2,24 +2,137 @@
 // This file is automatically generated. DO NOT EDIT
 
 
+/**
+ * ActionKind определяет, что действие делает с клавишами. Нулевое значение
+ * это нажатие с отпусканием, поэтому старые data.json без kind читаются как раньше.
+ */
+export enum ActionKind {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero = 0,
+
+    ActionTap = 0,
+    ActionDown = 1,
+    ActionUp = 2,
+    ActionHold = 3,
+    ActionDelay = 4,
+    ActionClick = 5,
+    ActionMouseDown = 6,
+    ActionMouseUp = 7,
+    ActionWheel = 8,
+    ActionMove = 9,
+    ActionMoveTo = 10,
+    ActionText = 11,
+};
+
 export interface AppData {
+    /**
+     * SchemaVersion проставляет хранилище при записи, см. storage.AppDataMigrations
+     */
+    "schema_version": number;
     "macros": Macro[] | null;
+    "settings": Settings;
 }
 
+/**
+ * Combo набор одновременно нажатых клавиш, порядок не важен.
+ * В каноничной форме коды отсортированы по возрастанию и не повторяются.
+ */
+export type Combo = number[] | null;
+
+/**
+ * Concurrency что делать, если последовательность нажали, пока она еще идет.
+ * Toggle и hold живут по своим правилам: повторное нажатие выключает toggle,
+ * а hold и так идет, пока держат клавиши.
+ */
+export enum Concurrency {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero = 0,
+
+    ConcurrencyParallel = 0,
+    ConcurrencyIgnore = 1,
+    ConcurrencyRestart = 2,
+
+    /**
+     * ConcurrencyQueue запускает еще один раз после текущего, больше одного не копит
+     */
+    ConcurrencyQueue = 3,
+};
+
+/**
+ * InputMode способ отправки клавиш. У действия InputModeDefault означает
+ * режим макроса, у макроса обычные virtual-key коды.
+ */
+export enum InputMode {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero = 0,
+
+    InputModeDefault = 0,
+    InputModeVirtualKey = 1,
+    InputModeScancode = 2,
+};
+
 export interface Macro {
     "id": string;
     "disabled": boolean;
     "name": string;
-    "activation_keys": number[] | null;
+    "activation_keys": Combo;
     "type": MacroType;
     "actions": MacroAction[] | null;
     "include_title": string[] | null;
+    "input_mode": InputMode;
+    "concurrency": Concurrency;
+
+    /**
+     * Humanize случайно меняет все паузы и удержания на +-столько процентов
+     */
+    "humanize": number;
+
+    /**
+     * Ограничения повторов для toggle и hold, 0 значит без ограничения
+     */
+    "repeat_count": number;
+    "max_duration": number;
+    "interval_between_repeats": number;
 }
 
 export interface MacroAction {
     "id": string;
+    "kind": ActionKind;
     "keys": number[] | null;
+
+    /**
+     * Duration сколько мс держать клавиши для ActionHold
+     */
+    "duration": number;
+    "button": MouseButton;
+
+    /**
+     * X и Y смещение для ActionMove или координаты виртуального рабочего стола для ActionMoveTo
+     */
+    "x": number;
+    "y": number;
+    "delta": number;
+
+    /**
+     * Text печатается посимвольно для ActionText, CharDelay пауза между символами в мс
+     */
+    "text": string;
+    "char_delay": number;
+    "input_mode": InputMode;
     "delay": number;
+
+    /**
+     * DelayMax больше Delay включает случайную паузу из диапазона [Delay, DelayMax],
+     * иначе DelayStdDev задает нормальный разброс вокруг Delay
+     */
+    "delay_max": number;
+    "delay_std_dev": number;
 }
 
 export enum MacroType {
@@ -32,3 +145,32 @@ export enum MacroType {
     MacroTypeToggle = 1,
     MacroTypeHold = 2,
 };
+
+/**
+ * MouseButton значения совпадают с input.MouseButton
+ */
+export enum MouseButton {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero = 0,
+
+    MouseLeft = 0,
+    MouseRight = 1,
+    MouseMiddle = 2,
+    MouseX1 = 3,
+    MouseX2 = 4,
+};
+
+export interface Settings {
+    /**
+     * PanicKeys останавливает все запущенные макросы, пустая комбинация отключает
+     */
+    "panic_keys": Combo;
+
+    /**
+     * Сколько снимков data.json хранить и не старше скольких дней, 0 по умолчанию
+     */
+    "backup_count": number;
+    "backup_days": number;
+}
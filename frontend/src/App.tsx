@@ -1,10 +1,12 @@
-import { ErrorBoundary } from "solid-js";
+import { ErrorBoundary, For } from "solid-js";
+import { useStore } from "@nanostores/solid";
 import { hashIntegration, Router } from "@solidjs/router";
 import { Route } from "@solidjs/router";
 import { Routes } from "@solidjs/router";
 import { MacrosList } from "./pages/MacrosList";
 import { MacrosForm } from "./pages/MacrosForm";
 import { Settings } from "./pages/Settings";
+import { $warnings, dismissWarning } from "./stores/warnings";
 
 function ErrorScreen(props: { err: Error }) {
   return (
@@ -20,9 +22,25 @@ function ErrorScreen(props: { err: Error }) {
   );
 }
 
+function Warnings() {
+  const warnings = useStore($warnings);
+
+  return (
+    <For each={warnings()}>
+      {(message, index) => (
+        <div class="flex items-center justify-between gap-3 px-4 py-2 text-sm bg-amber-900 text-amber-100">
+          <div>{message}</div>
+          <button onClick={() => dismissWarning(index())}>Закрыть</button>
+        </div>
+      )}
+    </For>
+  );
+}
+
 export function App() {
   return (
     <ErrorBoundary fallback={(err) => <ErrorScreen err={err as Error} />}>
+      <Warnings />
       <Router source={hashIntegration()}>
         <Routes>
           <Route path="/" component={MacrosList} />
@@ -8,8 +8,13 @@ import modalStyles from "../styles/Modal.module.css";
 
 import { createStore } from "solid-js/store";
 import {
+  ActionKind,
+  Concurrency,
+  InputMode,
   Macro,
+  MacroAction,
   MacroType,
+  MouseButton,
 } from "../../bindings/repeat-what-shit/internal/types";
 import { createSignal, For, onMount } from "solid-js";
 import { KeysPicker } from "../components/KeysPicker";
@@ -24,6 +29,66 @@ import { GetWindowList } from "../../bindings/repeat-what-shit/internal/app";
 
 import unknownFile from "../assets/unknown-file-types.png";
 
+// Клавиши нужны только действиям с клавиатурой, у паузы, мыши и текста их нет
+const keyActionKinds = [
+  ActionKind.ActionTap,
+  ActionKind.ActionDown,
+  ActionKind.ActionUp,
+  ActionKind.ActionHold,
+];
+
+const isKeyAction = (action: MacroAction) =>
+  keyActionKinds.includes(action.kind);
+
+const newAction = (): MacroAction => ({
+  id: generateId(),
+  kind: ActionKind.ActionTap,
+  keys: [],
+  duration: 0,
+  button: MouseButton.MouseLeft,
+  x: 0,
+  y: 0,
+  delta: 0,
+  text: "",
+  char_delay: 0,
+  input_mode: InputMode.InputModeDefault,
+  delay: 0,
+  delay_max: 0,
+  delay_std_dev: 0,
+});
+
+const mouseButtonNames: Record<MouseButton, string> = {
+  [MouseButton.MouseLeft]: "левая",
+  [MouseButton.MouseRight]: "правая",
+  [MouseButton.MouseMiddle]: "средняя",
+  [MouseButton.MouseX1]: "X1",
+  [MouseButton.MouseX2]: "X2",
+};
+
+// describeAction подпись для шагов, которые нельзя править через выбор клавиш
+const describeAction = (action: MacroAction) => {
+  switch (action.kind) {
+    case ActionKind.ActionDelay:
+      return "Пауза";
+    case ActionKind.ActionClick:
+      return `Клик мышью: ${mouseButtonNames[action.button]}`;
+    case ActionKind.ActionMouseDown:
+      return `Нажать кнопку мыши: ${mouseButtonNames[action.button]}`;
+    case ActionKind.ActionMouseUp:
+      return `Отпустить кнопку мыши: ${mouseButtonNames[action.button]}`;
+    case ActionKind.ActionWheel:
+      return `Колесо: ${action.delta}`;
+    case ActionKind.ActionMove:
+      return `Сдвиг мыши: ${action.x}, ${action.y}`;
+    case ActionKind.ActionMoveTo:
+      return `Мышь в точку: ${action.x}, ${action.y}`;
+    case ActionKind.ActionText:
+      return `Текст: ${action.text}`;
+    default:
+      return "";
+  }
+};
+
 type ValidationError = {
   path: string;
   message: string;
@@ -45,13 +110,25 @@ const validateMacro = (macro: Macro) => {
   if (!macro.actions || macro.actions.length === 0) {
     errors.push({ path: "actions", message: "Добавьте хотя бы одно действие" });
   } else {
-    macro.actions.forEach((action, index) => {
-      if (!action.keys || action.keys.length === 0) {
+    macro.actions.forEach((action) => {
+      if (isKeyAction(action) && !action.keys?.length) {
         errors.push({
           path: `actions.${action.id}.keys`,
           message: "Выберите клавиши для действия",
         });
       }
+      if (action.kind === ActionKind.ActionText && !action.text) {
+        errors.push({
+          path: `actions.${action.id}.keys`,
+          message: "Введите текст",
+        });
+      }
+      if (action.kind === ActionKind.ActionWheel && !action.delta) {
+        errors.push({
+          path: `actions.${action.id}.keys`,
+          message: "Укажите прокрутку колеса",
+        });
+      }
       if (action.delay < 0) {
         errors.push({
           path: `actions.${action.id}.delay`,
@@ -86,15 +163,15 @@ export function MacrosForm() {
     name: "",
     type: MacroType.MacroTypeSequence,
     disabled: false,
-    actions: [
-      {
-        id: generateId(),
-        keys: [],
-        delay: 0,
-      },
-    ],
+    actions: [newAction()],
     include_title: [],
     activation_keys: [],
+    input_mode: InputMode.InputModeDefault,
+    concurrency: Concurrency.ConcurrencyParallel,
+    humanize: 0,
+    repeat_count: 0,
+    max_duration: 0,
+    interval_between_repeats: 0,
   });
 
   const fetchWindowsList = async () => {
@@ -328,10 +405,7 @@ export function MacrosForm() {
         <div>Шаги макроса</div>
         <button
           onClick={() =>
-            setMacros("actions", (v) => [
-              ...(v || []),
-              { id: generateId(), keys: [], delay: 0 },
-            ])
+            setMacros("actions", (v) => [...(v || []), newAction()])
           }
           class={styles.addActionBtn}
         >
@@ -363,17 +437,23 @@ export function MacrosForm() {
           {(action) => (
             <div class={styles.action}>
               <div class={inputStyles.inputContainer}>
-                <KeysPicker
-                  value={action().keys || []}
-                  onChange={(combo) => {
-                    setMacros("actions", (v) => {
-                      const newActions = [...(v || [])].map((a) =>
-                        a.id === action().id ? { ...a, keys: combo } : a
-                      );
-                      return newActions;
-                    });
-                  }}
-                />
+                {isKeyAction(action()) ? (
+                  <KeysPicker
+                    value={action().keys || []}
+                    onChange={(combo) => {
+                      setMacros("actions", (v) => {
+                        const newActions = [...(v || [])].map((a) =>
+                          a.id === action().id ? { ...a, keys: combo } : a
+                        );
+                        return newActions;
+                      });
+                    }}
+                  />
+                ) : (
+                  <div class={inputStyles.input}>
+                    {describeAction(action())}
+                  </div>
+                )}
                 <div class={inputStyles.error}>
                   {getErrorByPath(`actions.${action().id}.keys`)}
                 </div>
@@ -74,12 +74,21 @@ export function MacrosList() {
     <ViewPort
       title="Список макросов"
       subContent={
-        <button
-          onClick={() => navigate("/macros/create")}
-          class={btnStyles.titleBtn}
-        >
-          Создать макрос
-        </button>
+        <div class="flex gap-3">
+          <button
+            onClick={() => navigate("/settings")}
+            class={btnStyles.titleBtn}
+          >
+            Настройки
+          </button>
+
+          <button
+            onClick={() => navigate("/macros/create")}
+            class={btnStyles.titleBtn}
+          >
+            Создать макрос
+          </button>
+        </div>
       }
     >
       {!app().macros?.length && (
@@ -1,3 +1,40 @@
+import { useStore } from "@nanostores/solid";
+import { ViewPort } from "../components/ViewPort";
+import { KeysPicker } from "../components/KeysPicker";
+import { $app, saveSettings } from "../stores/app";
+import { StopAllMacros } from "../../bindings/repeat-what-shit/internal/app";
+
+import btnStyles from "../styles/Buttons.module.css";
+import inputStyles from "../styles/Inputs.module.css";
+
 export function Settings() {
-  return <div>Settings</div>;
+  const app = useStore($app);
+
+  return (
+    <ViewPort
+      title="Настройки"
+      subContent={
+        <button
+          onClick={() => StopAllMacros()}
+          classList={{
+            [btnStyles.titleBtn]: true,
+            [btnStyles.titleBtnRemove]: true,
+          }}
+        >
+          Остановить все макросы
+        </button>
+      }
+    >
+      <div class={inputStyles.inputContainer}>
+        <div class={inputStyles.label}>Аварийная остановка</div>
+        <KeysPicker
+          value={app().settings.panic_keys || []}
+          onChange={(combo) =>
+            saveSettings({ ...app().settings, panic_keys: combo })
+          }
+        />
+        <div class={inputStyles.error} />
+      </div>
+    </ViewPort>
+  );
 }
@@ -1,24 +1,50 @@
 import { map, onMount } from "nanostores";
+import { Events } from "@wailsio/runtime";
 import {
   ReadAppData,
   WriteAppData,
 } from "../../bindings/repeat-what-shit/internal/app";
-import { AppData, Macro } from "../../bindings/repeat-what-shit/internal/types";
+import {
+  AppData,
+  Macro,
+  Settings,
+} from "../../bindings/repeat-what-shit/internal/types";
+import { addWarning } from "./warnings";
 
 export const $app = map<AppData>({
+  schema_version: 0,
   macros: [],
+  settings: { panic_keys: [], backup_count: 0, backup_days: 0 },
 });
 
 onMount($app, () => {
   ReadAppData().then((data) => $app.set(data));
+
+  // Приходит после каждой записи, в том числе правок data.json снаружи
+  return Events.On(
+    "app_data_changed",
+    ({ data: [data] }: { data: [AppData] }) => $app.set(data)
+  );
 });
 
-export async function saveMacros(macros: Macro[]) {
-  const data: AppData = { macros };
-  await WriteAppData(data);
+async function save(data: AppData) {
+  try {
+    await WriteAppData(data);
+  } catch (err) {
+    addWarning(`Не удалось сохранить данные: ${err}`);
+    return;
+  }
   $app.set(data);
 }
 
+export async function saveMacros(macros: Macro[]) {
+  await save({ ...$app.get(), macros });
+}
+
+export async function saveSettings(settings: Settings) {
+  await save({ ...$app.get(), settings });
+}
+
 export async function addMacro(macro: Macro) {
   const { macros } = $app.get();
   await saveMacros([...(macros || []), macro]);
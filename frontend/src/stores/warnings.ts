@@ -0,0 +1,20 @@
+import { atom } from "nanostores";
+import { Events } from "@wailsio/runtime";
+
+// Предупреждения о данных: data.json поднят из копии, правка снаружи
+// не прочиталась или запись не удалась. Висят, пока их не закроют.
+export const $warnings = atom<string[]>([]);
+
+export function addWarning(message: string) {
+  $warnings.set([...$warnings.get(), message]);
+}
+
+export function dismissWarning(index: number) {
+  $warnings.set($warnings.get().filter((_, i) => i !== index));
+}
+
+// Подписка при загрузке модуля, а не в компоненте: предупреждения о запуске
+// приходят сразу, как только готов runtime
+Events.On("storage_warning", ({ data: [message] }: { data: [string] }) =>
+  addWarning(message)
+);
@@ -5,6 +5,7 @@ go 1.22.4
 toolchain go1.23.3
 
 require (
+	github.com/jezek/xgb v1.1.1
 	github.com/moutend/go-hook v0.1.0
 	github.com/wailsapp/wails/v3 v3.0.0-alpha.9
 )
@@ -1,12 +1,19 @@
 package internal
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"repeat-what-shit/internal/executor"
 	"repeat-what-shit/internal/hotkeys"
 	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/recorder"
+	"repeat-what-shit/internal/runtime"
 	"repeat-what-shit/internal/storage"
 	"repeat-what-shit/internal/types"
 	"repeat-what-shit/internal/utils"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -15,45 +22,57 @@ import (
 type App struct {
 	Storage *storage.JsonStorage[types.AppData]
 	Version string
+	Input   input.Injector
+	Events  hotkeys.EventSource
 
 	HotkeyService *hotkeys.HotkeyService
+	Runtime       *runtime.Registry
+
+	captureMu     sync.Mutex
 	captureMode   bool
-	lastCombo     []int
+	lastCombo     types.Combo
 	lastComboTime uint32
 
-	activeMacros map[string]chan bool
+	recorder *recorder.Recorder
+
+	// data снимок из Storage для матчера хоткеев, обновляется по подписке
+	data atomic.Pointer[types.AppData]
 }
 
 func (a *App) SetupHotkeys() {
-	a.activeMacros = make(map[string]chan bool)
-	a.HotkeyService = hotkeys.NewHotkeyService()
+	a.HotkeyService = hotkeys.NewHotkeyService(a.Events)
+	a.Runtime = runtime.New(executor.New(a.Input))
+	a.recorder = recorder.New()
+	a.HotkeyService.OnEvent(a.recorder.Add)
+
+	a.setData(a.Storage.GetData())
+	a.Storage.Subscribe(a.setData)
 
 	a.HotkeyService.Start(func(combo hotkeys.KeyCombo) {
 		log.Println(combo.Keys)
 
-		if a.captureMode {
-			if len(combo.Keys) == 0 {
-				a.lastCombo = nil
-				return
-			}
+		if a.handleCapture(combo) {
+			return
+		}
 
-			if len(combo.Keys) < len(a.lastCombo) {
-				return
-			}
+		data := a.data.Load()
 
-			if len(combo.Keys) > len(a.lastCombo) || !equalCombos(combo.Keys, a.lastCombo) {
-				a.lastCombo = append([]int(nil), combo.Keys...)
-				application.Get().EmitEvent("captured_combo", combo.Keys)
-			}
+		panicKeys := data.Settings.PanicKeys
+		if !panicKeys.IsEmpty() && hotkeys.Match(combo.Keys, panicKeys) {
+			a.StopAllMacros()
 			return
 		}
 
-		for _, macro := range a.Storage.GetData().Macros {
+		if a.recorder.IsRecording() {
+			return
+		}
+
+		for _, macro := range data.Macros {
 			if macro.Disabled {
 				continue
 			}
 
-			if !equalCombos(combo.Keys, macro.ActivationKeys) {
+			if !hotkeys.Match(combo.Keys, macro.ActivationKeys) {
 				continue
 			}
 
@@ -63,109 +82,146 @@ func (a *App) SetupHotkeys() {
 
 			switch macro.Type {
 			case types.MacroTypeSequence:
-				go a.executeMacro(macro)
+				a.Runtime.Start(macro, nil)
 
 			case types.MacroTypeToggle:
-				if stopCh, exists := a.activeMacros[macro.ID]; exists {
-					close(stopCh)
-					delete(a.activeMacros, macro.ID)
-				} else {
-					stopCh := make(chan bool)
-					a.activeMacros[macro.ID] = stopCh
-					go a.executeToggleMacro(macro, stopCh)
-				}
+				a.Runtime.Toggle(macro, nil)
 
 			case types.MacroTypeHold:
-				if _, exists := a.activeMacros[macro.ID]; !exists {
-					stopCh := make(chan bool)
-					a.activeMacros[macro.ID] = stopCh
-					go a.executeHoldMacro(macro, stopCh)
-				}
+				a.Runtime.Start(macro, func() bool {
+					return a.HotkeyService.IsComboPressed(macro.ActivationKeys)
+				})
 			}
 		}
 	})
 }
 
-func (a *App) executeMacro(macro types.Macro) {
-	for _, action := range macro.Actions {
-		input.SendInput(action.Keys)
-		if action.Delay > 0 {
-			time.Sleep(time.Duration(action.Delay) * time.Millisecond)
-		}
-	}
-}
-
-func (a *App) executeToggleMacro(macro types.Macro, stopCh chan bool) {
-	for {
-		select {
-		case <-stopCh:
-			return
-		default:
-			a.executeMacro(macro)
-		}
-	}
+func (a *App) setData(data types.AppData) {
+	a.data.Store(&data)
 }
 
-func (a *App) executeHoldMacro(macro types.Macro, stopCh chan bool) {
-	for {
-		if !hotkeys.IsComboPressed(macro.ActivationKeys) {
-			if ch, exists := a.activeMacros[macro.ID]; exists {
-				close(ch)
-				delete(a.activeMacros, macro.ID)
-			}
-			return
-		}
+// handleCapture в режиме захвата запоминает самую полную нажатую комбинацию
+// и отдает ее фронту, true если событие съедено захватом
+func (a *App) handleCapture(combo hotkeys.KeyCombo) bool {
+	a.captureMu.Lock()
+	defer a.captureMu.Unlock()
 
-		select {
-		case <-stopCh:
-			return
-		default:
-			for _, action := range macro.Actions {
-				input.SendInput(action.Keys)
-				if action.Delay > 0 {
-					time.Sleep(time.Duration(action.Delay) * time.Millisecond)
-				}
-			}
+	if !a.captureMode {
+		return false
+	}
 
-			if !hotkeys.IsComboPressed(macro.ActivationKeys) {
-				if ch, exists := a.activeMacros[macro.ID]; exists {
-					close(ch)
-					delete(a.activeMacros, macro.ID)
-				}
-				return
-			}
-		}
+	if len(combo.Keys) == 0 {
+		a.lastCombo = nil
+		return true
 	}
-}
 
-func equalCombos(a, b []int) bool {
-	if len(a) != len(b) {
-		return false
+	if len(combo.Keys) < len(a.lastCombo) {
+		return true
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
+
+	if len(combo.Keys) > len(a.lastCombo) || combo.Keys.String() != a.lastCombo.String() {
+		a.lastCombo = combo.Keys
+		application.Get().EmitEvent("captured_combo", combo.Keys)
 	}
 	return true
 }
 
+// StopAllMacros аварийно останавливает все макросы, включая одиночные
+// последовательности, и отпускает все, что они оставили нажатым
+func (a *App) StopAllMacros() {
+	a.Runtime.StopAll()
+}
+
+// MeasureTiming замеряет, насколько точно на этой машине выдерживаются паузы
+func (a *App) MeasureTiming(delay int, count int) (executor.TimingReport, error) {
+	return executor.Measure(context.Background(), time.Duration(delay)*time.Millisecond, count)
+}
+
+// GetRunningMacros список запущенных сейчас макросов
+func (a *App) GetRunningMacros() []runtime.Instance {
+	return a.Runtime.List()
+}
+
 func (a *App) StartCapture() {
+	a.captureMu.Lock()
+	defer a.captureMu.Unlock()
 	a.captureMode = true
 }
 
 func (a *App) StopCapture() {
+	a.captureMu.Lock()
+	defer a.captureMu.Unlock()
 	a.captureMode = false
 	a.lastCombo = nil
 	a.lastComboTime = 0
 }
 
+// StartRecording начинает запись всего ввода, макросы на это время не срабатывают
+func (a *App) StartRecording() {
+	a.recorder.Start()
+}
+
+// StopRecording завершает запись и возвращает действия с реальными задержками
+func (a *App) StopRecording() []types.MacroAction {
+	return recorder.Convert(a.recorder.Stop())
+}
+
+// ProcessRecording чистит записанные действия перед сохранением в макрос
+func (a *App) ProcessRecording(actions []types.MacroAction, opts recorder.Options) []types.MacroAction {
+	return recorder.Process(actions, opts)
+}
+
+// EmitStartupWarnings показывает фронту проблемы, найденные при запуске,
+// например что data.json был битый и данные подняты из резервной копии
+func (a *App) EmitStartupWarnings() {
+	if err := a.Storage.Recovered(); err != nil {
+		application.Get().EmitEvent("storage_warning", err.Error())
+	}
+}
+
+// WatchStorage подхватывает правки data.json, сделанные в обход приложения.
+// Новые данные фронт получает тем же app_data_changed, что и после своих
+// записей, об ошибках узнает из storage_warning.
+func (a *App) WatchStorage() {
+	_, err := a.Storage.Watch(func(err error) {
+		if err != nil {
+			application.Get().EmitEvent("storage_warning", fmt.Sprintf("data.json was changed but not loaded: %v", err))
+		}
+	})
+	if err != nil {
+		log.Println("failed to watch data.json:", err)
+	}
+}
+
 func (a *App) ReadAppData() types.AppData {
 	return a.Storage.GetData()
 }
 
-func (a *App) WriteAppData(data types.AppData) {
-	a.Storage.Write(data)
+// WriteAppData сохраняет данные, при ошибке записи они остаются прежними
+func (a *App) WriteAppData(data types.AppData) error {
+	a.ApplyBackupSettings(data.Settings)
+	return a.Storage.Write(data)
+}
+
+// ApplyBackupSettings передает хранилищу лимиты снимков из настроек
+func (a *App) ApplyBackupSettings(settings types.Settings) {
+	if backups := a.Storage.Backups(); backups != nil {
+		if err := backups.SetLimits(settings.BackupCount, time.Duration(settings.BackupDays)*24*time.Hour); err != nil {
+			log.Println("failed to apply backup limits:", err)
+		}
+	}
+}
+
+func (a *App) ListBackups() ([]storage.BackupInfo, error) {
+	if a.Storage.Backups() == nil {
+		return nil, nil
+	}
+	return a.Storage.Backups().List()
+}
+
+// RestoreBackup откатывает data.json к снимку с именем из ListBackups
+func (a *App) RestoreBackup(name string) error {
+	return a.Storage.RestoreBackup(name)
 }
 
 func (a *App) GetVersion() string {
@@ -0,0 +1,238 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Executor проигрывает макросы через injector. Отмена контекста прерывает
+// текущую паузу, а все, что макрос успел нажать, отпускается.
+type Executor struct {
+	input input.Injector
+
+	// pressed все, что нажато любым запуском и еще не отпущено. Последовательность
+	// может закончиться с зажатой клавишей, и отпустить ее потом может только ReleaseAll.
+	pressed *input.TrackingInjector
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+func New(injector input.Injector) *Executor {
+	return NewSeeded(injector, rand.Uint64())
+}
+
+// NewSeeded executor с предсказуемыми случайными паузами, для тестов
+func NewSeeded(injector input.Injector, seed uint64) *Executor {
+	return &Executor{
+		input:   injector,
+		pressed: input.NewTrackingInjector(injector),
+		rng:     rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// session одно выполнение макроса со своим списком нажатых клавиш
+type session struct {
+	e       *Executor
+	ctx     context.Context
+	macro   types.Macro
+	tracker *input.TrackingInjector
+	time    timeline
+
+	restoreTimer func()
+}
+
+func (e *Executor) newSession(ctx context.Context, macro types.Macro) *session {
+	return &session{
+		e:            e,
+		ctx:          ctx,
+		macro:        macro,
+		tracker:      input.NewTrackingInjector(e.pressed),
+		time:         newTimeline(),
+		restoreTimer: raiseTimerResolution(),
+	}
+}
+
+// finish отпускает клавиши только при отмене или ошибке: макрос может
+// намеренно оставить клавишу зажатой
+func (s *session) finish(err error) {
+	s.restoreTimer()
+	if err != nil || s.ctx.Err() != nil {
+		s.tracker.ReleaseAll()
+	}
+}
+
+// ReleaseAll отпускает все, что макросы оставили нажатым, в том числе
+// уже закончившиеся. Запущенные в этот момент макросы надо сначала остановить.
+func (e *Executor) ReleaseAll() {
+	e.pressed.ReleaseAll()
+}
+
+// wait ждет d по шкале сессии или отмены контекста
+func (s *session) wait(d time.Duration) error {
+	return s.time.wait(s.ctx, d)
+}
+
+// Run выполняет действия макроса один раз
+func (e *Executor) Run(ctx context.Context, macro types.Macro) error {
+	s := e.newSession(ctx, macro)
+	err := s.sequence()
+	s.finish(err)
+	return err
+}
+
+// Repeat повторяет макрос до отмены, пока while возвращает true
+// и пока не кончились лимиты RepeatCount и MaxDuration. MaxDuration
+// обрывает и повтор, который идет в этот момент.
+func (e *Executor) Repeat(ctx context.Context, macro types.Macro, while func() bool) error {
+	parent := ctx
+	if macro.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ms(macro.MaxDuration))
+		defer cancel()
+	}
+
+	s := e.newSession(ctx, macro)
+	err := s.repeat(while)
+	s.finish(err)
+	if err != nil && parent.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
+
+func (s *session) repeat(while func() bool) error {
+	for count := 0; s.macro.RepeatCount <= 0 || count < s.macro.RepeatCount; count++ {
+		if count > 0 {
+			if err := s.wait(s.e.duration(s.macro, float64(s.macro.IntervalBetweenRepeats))); err != nil {
+				return err
+			}
+		}
+
+		if while != nil && !while() {
+			return nil
+		}
+
+		if err := s.sequence(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func (s *session) sequence() error {
+	for _, action := range s.macro.Actions {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.action(s.injectorFor(action), action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// injectorFor выбирает режим отправки клавиш: действие может переопределить режим макроса
+func (s *session) injectorFor(action types.MacroAction) input.Injector {
+	mode := s.macro.InputMode
+	if action.InputMode != types.InputModeDefault {
+		mode = action.InputMode
+	}
+
+	if mode == types.InputModeScancode {
+		return input.WithMode(s.tracker, input.ModeScancode)
+	}
+	return s.tracker
+}
+
+// action выполняет одно действие. Ошибка отправки ввода прерывает макрос,
+// иначе он играл бы дальше с пропущенными нажатиями.
+func (s *session) action(injector input.Injector, action types.MacroAction) error {
+	var err error
+	switch action.Kind {
+	case types.ActionTap:
+		err = injector.Tap(action.Keys)
+
+	case types.ActionDown:
+		err = keysDown(injector, action.Keys)
+
+	case types.ActionUp:
+		err = keysUp(injector, action.Keys)
+
+	case types.ActionHold:
+		if err = keysDown(injector, action.Keys); err != nil {
+			break
+		}
+		waitErr := s.wait(s.e.duration(s.macro, float64(action.Duration)))
+		err = keysUp(injector, action.Keys)
+		if waitErr != nil {
+			return waitErr
+		}
+
+	case types.ActionClick:
+		err = input.Click(injector, input.MouseButton(action.Button))
+
+	case types.ActionMouseDown:
+		err = injector.Mouse(input.MouseButton(action.Button), true)
+
+	case types.ActionMouseUp:
+		err = injector.Mouse(input.MouseButton(action.Button), false)
+
+	case types.ActionWheel:
+		err = injector.Wheel(action.Delta)
+
+	case types.ActionMove:
+		err = injector.Move(action.X, action.Y)
+
+	case types.ActionMoveTo:
+		err = injector.MoveTo(action.X, action.Y)
+
+	case types.ActionText:
+		// \r\n из вставленного текста это один перевод строки, а не два Enter
+		for _, r := range strings.ReplaceAll(action.Text, "\r\n", "\n") {
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			if err := injector.TypeRune(r); err != nil {
+				return fmt.Errorf("failed to type %q: %w", r, err)
+			}
+			if err := s.wait(s.e.duration(s.macro, float64(action.CharDelay))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to send input: %w", err)
+	}
+	return s.wait(s.e.delay(s.macro, action))
+}
+
+func keysDown(injector input.Injector, keys []int) error {
+	for _, key := range keys {
+		if err := injector.KeyDown(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keysUp отпускает в обратном порядке и пробует отпустить все, даже если одна клавиша не отпустилась
+func keysUp(injector input.Injector, keys []int) error {
+	var errs []error
+	for i := len(keys) - 1; i >= 0; i-- {
+		errs = append(errs, injector.KeyUp(keys[i]))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,301 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
+	"testing"
+	"time"
+)
+
+const (
+	vkA = 0x41
+	vkB = 0x42
+)
+
+func tapMacro(delay int) types.Macro {
+	return types.Macro{
+		Actions: []types.MacroAction{
+			{Kind: types.ActionTap, Keys: []int{vkA}, Delay: delay},
+		},
+	}
+}
+
+func countOps(records []input.Record, op input.Op, key int) int {
+	count := 0
+	for _, r := range records {
+		if r.Op == op && r.Key == key {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRepeatCount(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := tapMacro(0)
+	macro.RepeatCount = 3
+
+	if err := e.Repeat(context.Background(), macro, nil); err != nil {
+		t.Fatalf("Repeat() error: %v", err)
+	}
+	if got := countOps(injector.Records(), input.OpKeyDown, vkA); got != 3 {
+		t.Errorf("macro ran %d times, want 3", got)
+	}
+}
+
+func TestRepeatWhile(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	calls := 0
+	err := e.Repeat(context.Background(), tapMacro(0), func() bool {
+		calls++
+		return calls <= 2
+	})
+	if err != nil {
+		t.Fatalf("Repeat() error: %v", err)
+	}
+	if got := countOps(injector.Records(), input.OpKeyDown, vkA); got != 2 {
+		t.Errorf("macro ran %d times, want 2", got)
+	}
+}
+
+func TestIntervalBetweenRepeats(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := tapMacro(0)
+	macro.RepeatCount = 3
+	macro.IntervalBetweenRepeats = 30
+
+	start := time.Now()
+	if err := e.Repeat(context.Background(), macro, nil); err != nil {
+		t.Fatalf("Repeat() error: %v", err)
+	}
+	// Между тремя повторами две паузы, после последнего паузы нет
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond || elapsed > time.Second {
+		t.Errorf("3 repeats with 30ms interval took %v, want about 60ms", elapsed)
+	}
+	if got := countOps(injector.Records(), input.OpKeyDown, vkA); got != 3 {
+		t.Errorf("macro ran %d times, want 3", got)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := tapMacro(10)
+	macro.MaxDuration = 50
+
+	start := time.Now()
+	if err := e.Repeat(context.Background(), macro, nil); err != nil {
+		t.Fatalf("Repeat() error: %v, want nil when MaxDuration ends the macro", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Repeat with MaxDuration 50ms took %v", elapsed)
+	}
+	if got := countOps(injector.Records(), input.OpKeyDown, vkA); got < 2 {
+		t.Errorf("macro ran %d times in 50ms, want several", got)
+	}
+}
+
+func TestMaxDurationCutsCurrentRepeat(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := types.Macro{
+		MaxDuration: 30,
+		Actions: []types.MacroAction{
+			{Kind: types.ActionHold, Keys: []int{vkA}, Duration: 10_000},
+		},
+	}
+
+	start := time.Now()
+	if err := e.Repeat(context.Background(), macro, nil); err != nil {
+		t.Fatalf("Repeat() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("MaxDuration did not cut the hold, Repeat took %v", elapsed)
+	}
+
+	records := injector.Records()
+	if countOps(records, input.OpKeyDown, vkA) != 1 || countOps(records, input.OpKeyUp, vkA) != 1 {
+		t.Errorf("hold was not released after MaxDuration: %+v", records)
+	}
+}
+
+func TestMaxDurationParentCancel(t *testing.T) {
+	e := NewSeeded(input.NewMemoryInjector(), 1)
+
+	macro := tapMacro(10)
+	macro.MaxDuration = 10_000
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// Отмена снаружи остается ошибкой, в отличие от собственного MaxDuration
+	if err := e.Repeat(ctx, macro, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Repeat() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// waitRecord ждет, пока injector запишет нужную операцию
+func waitRecord(t *testing.T, injector *input.MemoryInjector, op input.Op, key int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for countOps(injector.Records(), op, key) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("op %v for key %#x was not sent", op, key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// runAsync запускает Run и отдает его результат в канал
+func runAsync(e *Executor, ctx context.Context, macro types.Macro) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- e.Run(ctx, macro) }()
+	return result
+}
+
+func waitResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+		return nil
+	}
+}
+
+func TestCancelDuringHoldReleasesKeys(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := types.Macro{
+		Actions: []types.MacroAction{
+			{Kind: types.ActionDown, Keys: []int{vkA}},
+			{Kind: types.ActionHold, Keys: []int{vkB}, Duration: 10_000},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runAsync(e, ctx, macro)
+	waitRecord(t, injector, input.OpKeyDown, vkB)
+	cancel()
+
+	if err := waitResult(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	records := injector.Records()
+	for _, key := range []int{vkA, vkB} {
+		if countOps(records, input.OpKeyUp, key) != 1 {
+			t.Errorf("key %#x was not released once on cancel: %+v", key, records)
+		}
+	}
+}
+
+func TestCancelDuringDelayReleasesMouse(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := types.Macro{
+		Actions: []types.MacroAction{
+			{Kind: types.ActionMouseDown, Button: types.MouseLeft, Delay: 10_000},
+			{Kind: types.ActionTap, Keys: []int{vkA}},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runAsync(e, ctx, macro)
+	waitRecord(t, injector, input.OpMouseDown, 0)
+	cancel()
+
+	if err := waitResult(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	records := injector.Records()
+	if countOps(records, input.OpMouseUp, 0) != 1 {
+		t.Errorf("mouse button was not released on cancel: %+v", records)
+	}
+	if countOps(records, input.OpKeyDown, vkA) != 0 {
+		t.Errorf("actions after cancel were sent: %+v", records)
+	}
+}
+
+func TestFinishedMacroKeepsKeysUntilReleaseAll(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(injector, 1)
+
+	macro := types.Macro{
+		Actions: []types.MacroAction{{Kind: types.ActionDown, Keys: []int{vkA}}},
+	}
+	if err := e.Run(context.Background(), macro); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if countOps(injector.Records(), input.OpKeyUp, vkA) != 0 {
+		t.Fatal("key held on purpose was released when the macro finished")
+	}
+
+	e.ReleaseAll()
+	if countOps(injector.Records(), input.OpKeyUp, vkA) != 1 {
+		t.Fatal("ReleaseAll did not release the key left by a finished macro")
+	}
+}
+
+var errInjector = errors.New("injector failed")
+
+// failingInjector отказывается нажимать клавишу failKey
+type failingInjector struct {
+	*input.MemoryInjector
+	failKey int
+}
+
+func (f *failingInjector) KeyDown(key int) error {
+	if key == f.failKey {
+		return errInjector
+	}
+	return f.MemoryInjector.KeyDown(key)
+}
+
+func (f *failingInjector) Tap(keys []int) error {
+	for _, key := range keys {
+		if key == f.failKey {
+			return errInjector
+		}
+	}
+	return f.MemoryInjector.Tap(keys)
+}
+
+func TestInjectorErrorStopsMacro(t *testing.T) {
+	const vkC = 0x43
+	injector := input.NewMemoryInjector()
+	e := NewSeeded(&failingInjector{MemoryInjector: injector, failKey: vkB}, 1)
+
+	macro := types.Macro{
+		Actions: []types.MacroAction{
+			{Kind: types.ActionDown, Keys: []int{vkA}},
+			{Kind: types.ActionDown, Keys: []int{vkB}},
+			{Kind: types.ActionTap, Keys: []int{vkC}},
+		},
+	}
+
+	err := e.Run(context.Background(), macro)
+	if !errors.Is(err, errInjector) {
+		t.Fatalf("Run() error = %v, want the injector error", err)
+	}
+
+	records := injector.Records()
+	if countOps(records, input.OpKeyDown, vkC) != 0 {
+		t.Errorf("macro went on after the injector error: %+v", records)
+	}
+	if countOps(records, input.OpKeyUp, vkA) != 1 {
+		t.Errorf("keys pressed before the error were not released: %+v", records)
+	}
+}
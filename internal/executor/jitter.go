@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"math/rand/v2"
+	"repeat-what-shit/internal/types"
+	"time"
+)
+
+// random общий для всех сессий генератор, rand.Rand сам по себе не потокобезопасен
+func (e *Executor) random(fn func(rng *rand.Rand) float64) float64 {
+	e.rngMu.Lock()
+	defer e.rngMu.Unlock()
+	return fn(e.rng)
+}
+
+// humanize случайно меняет значение на +-macro.Humanize процентов
+func (e *Executor) humanize(macro types.Macro, value float64) float64 {
+	if macro.Humanize <= 0 || value <= 0 {
+		return value
+	}
+
+	spread := float64(macro.Humanize) / 100
+	return value * (1 + spread*(e.random((*rand.Rand).Float64)*2-1))
+}
+
+// delay пауза после действия с учетом разброса и humanize
+func (e *Executor) delay(macro types.Macro, action types.MacroAction) time.Duration {
+	value := float64(action.Delay)
+
+	switch {
+	case action.DelayMax > action.Delay:
+		value += e.random((*rand.Rand).Float64) * float64(action.DelayMax-action.Delay)
+	case action.DelayStdDev > 0:
+		value += e.random((*rand.Rand).NormFloat64) * float64(action.DelayStdDev)
+	}
+
+	return e.duration(macro, value)
+}
+
+// duration переводит мс в time.Duration с humanize, отрицательные паузы обрезаются
+func (e *Executor) duration(macro types.Macro, value float64) time.Duration {
+	value = e.humanize(macro, value)
+	if value <= 0 {
+		return 0
+	}
+	return time.Duration(value * float64(time.Millisecond))
+}
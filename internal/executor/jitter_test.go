@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
+	"testing"
+	"time"
+)
+
+const samples = 10_000
+
+func newTestExecutor(seed uint64) *Executor {
+	return NewSeeded(input.NewMemoryInjector(), seed)
+}
+
+func TestDelayFixed(t *testing.T) {
+	e := newTestExecutor(1)
+	action := types.MacroAction{Delay: 40}
+
+	for i := 0; i < 100; i++ {
+		if got := e.delay(types.Macro{}, action); got != 40*time.Millisecond {
+			t.Fatalf("delay() = %v, want 40ms", got)
+		}
+	}
+}
+
+func TestDelaySeeded(t *testing.T) {
+	macro := types.Macro{Humanize: 20}
+	action := types.MacroAction{Delay: 50, DelayMax: 150}
+
+	a, b := newTestExecutor(42), newTestExecutor(42)
+	other := newTestExecutor(43)
+
+	same := true
+	for i := 0; i < 100; i++ {
+		got, want := a.delay(macro, action), b.delay(macro, action)
+		if got != want {
+			t.Fatalf("sample %d: executors with the same seed gave %v and %v", i, got, want)
+		}
+		if other.delay(macro, action) != got {
+			same = false
+		}
+	}
+	if same {
+		t.Fatal("executors with different seeds gave the same delays")
+	}
+}
+
+func TestDelayRange(t *testing.T) {
+	e := newTestExecutor(1)
+	action := types.MacroAction{Delay: 50, DelayMax: 150}
+
+	var minDelay, maxDelay time.Duration = time.Hour, 0
+	for i := 0; i < samples; i++ {
+		d := e.delay(types.Macro{}, action)
+		minDelay, maxDelay = min(minDelay, d), max(maxDelay, d)
+	}
+
+	if minDelay < 50*time.Millisecond || maxDelay > 150*time.Millisecond {
+		t.Fatalf("delays out of [50ms, 150ms]: %v..%v", minDelay, maxDelay)
+	}
+	// Равномерное распределение должно почти заполнить диапазон
+	if minDelay > 55*time.Millisecond || maxDelay < 145*time.Millisecond {
+		t.Fatalf("delays do not cover the range: %v..%v", minDelay, maxDelay)
+	}
+}
+
+func TestDelayStdDev(t *testing.T) {
+	e := newTestExecutor(1)
+	action := types.MacroAction{Delay: 100, DelayStdDev: 10}
+
+	var sum, sumSq float64
+	for i := 0; i < samples; i++ {
+		d := toMs(e.delay(types.Macro{}, action))
+		sum += d
+		sumSq += d * d
+	}
+
+	mean := sum / samples
+	variance := sumSq/samples - mean*mean
+	if abs(mean-100) > 1 {
+		t.Errorf("mean = %.2f, want about 100", mean)
+	}
+	if variance < 81 || variance > 121 {
+		t.Errorf("variance = %.2f, want about 100", variance)
+	}
+}
+
+func TestDelayStdDevNeverNegative(t *testing.T) {
+	e := newTestExecutor(1)
+	action := types.MacroAction{Delay: 5, DelayStdDev: 50}
+
+	for i := 0; i < samples; i++ {
+		if d := e.delay(types.Macro{}, action); d < 0 {
+			t.Fatalf("delay() = %v", d)
+		}
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	e := newTestExecutor(1)
+	macro := types.Macro{Humanize: 10}
+
+	var lower, higher bool
+	for i := 0; i < samples; i++ {
+		v := e.humanize(macro, 200)
+		if v < 180 || v > 220 {
+			t.Fatalf("humanize(200) = %.2f, want within 10%%", v)
+		}
+		lower = lower || v < 190
+		higher = higher || v > 210
+	}
+	if !lower || !higher {
+		t.Fatal("humanize does not spread both ways")
+	}
+
+	if v := e.humanize(macro, 0); v != 0 {
+		t.Fatalf("humanize(0) = %.2f, zero delays must stay zero", v)
+	}
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+const (
+	// Последние миллисекунды перед дедлайном крутимся в цикле,
+	// обычный таймер на них часто просыпается слишком поздно
+	spinThreshold = 2 * time.Millisecond
+	// Больше этого опоздание не возмещаем, иначе после долгого зависания
+	// макрос отыграл бы несколько пауз подряд без ожидания
+	maxDebt = 50 * time.Millisecond
+)
+
+// timeline ведет абсолютное расписание одного выполнения макроса: каждая пауза
+// заканчивается в start + сумма всех пауз до нее. Время, ушедшее на сами
+// действия и на опоздание таймера, вычитается из следующей паузы, поэтому
+// на повторах ошибка не копится.
+type timeline struct {
+	next time.Time
+
+	// clock и sleep подменяются в тестах
+	clock func() time.Time
+	sleep func(ctx context.Context, deadline time.Time) error
+}
+
+func newTimeline() timeline {
+	return timeline{next: time.Now(), clock: time.Now, sleep: sleepUntil}
+}
+
+func (t *timeline) wait(ctx context.Context, d time.Duration) error {
+	now := t.clock()
+	if behind := now.Sub(t.next); behind > maxDebt {
+		t.next = now.Add(-maxDebt)
+	}
+
+	t.next = t.next.Add(d)
+	if !t.next.After(now) {
+		return ctx.Err()
+	}
+	return t.sleep(ctx, t.next)
+}
+
+// sleepUntil спит обычным таймером почти до дедлайна, остаток докручивает в цикле
+func sleepUntil(ctx context.Context, deadline time.Time) error {
+	if coarse := time.Until(deadline) - spinThreshold; coarse > 0 {
+		timer := time.NewTimer(coarse)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}
+
+// TimingReport результат замера точности пауз, все значения в мс
+type TimingReport struct {
+	Requested float64 `json:"requested"`
+	Count     int     `json:"count"`
+	Mean      float64 `json:"mean"`
+	MaxError  float64 `json:"max_error"`
+	// Drift насколько вся серия отклонилась от count*requested
+	Drift float64 `json:"drift"`
+}
+
+// Measure выполняет count пауз по requested через тот же планировщик,
+// что и макросы, и сравнивает с тем, сколько прошло на самом деле
+func Measure(ctx context.Context, requested time.Duration, count int) (TimingReport, error) {
+	defer raiseTimerResolution()()
+
+	report := TimingReport{Requested: toMs(requested), Count: count}
+	if count <= 0 {
+		return report, nil
+	}
+
+	t := newTimeline()
+	started := t.next
+	last := started
+
+	for i := 0; i < count; i++ {
+		if err := t.wait(ctx, requested); err != nil {
+			return report, err
+		}
+
+		now := time.Now()
+		actual := now.Sub(last)
+		last = now
+
+		report.MaxError = max(report.MaxError, abs(toMs(actual-requested)))
+	}
+
+	total := last.Sub(started)
+	report.Mean = toMs(total) / float64(count)
+	report.Drift = toMs(total - requested*time.Duration(count))
+	return report, nil
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock время для timeline без настоящих пауз. Каждый сон просыпается
+// на late позже дедлайна, как обычный таймер Windows.
+type fakeClock struct {
+	now       time.Time
+	late      time.Duration
+	deadlines []time.Time
+}
+
+func newFakeTimeline(clock *fakeClock) timeline {
+	return timeline{
+		next:  clock.now,
+		clock: func() time.Time { return clock.now },
+		sleep: func(ctx context.Context, deadline time.Time) error {
+			clock.deadlines = append(clock.deadlines, deadline)
+			clock.now = deadline.Add(clock.late)
+			return ctx.Err()
+		},
+	}
+}
+
+func TestTimelineSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start, late: 3 * time.Millisecond}
+	tl := newFakeTimeline(clock)
+
+	const period = 20 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		// Само действие тоже занимает время, например tapDelay в Tap
+		clock.now = clock.now.Add(10 * time.Millisecond)
+		if err := tl.wait(context.Background(), period); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for n, deadline := range clock.deadlines {
+		if want := start.Add(time.Duration(n+1) * period); !deadline.Equal(want) {
+			t.Errorf("step %d deadline = %v after start, want %v", n, deadline.Sub(start), want.Sub(start))
+		}
+	}
+	if len(clock.deadlines) != 10 {
+		t.Errorf("slept %d times, want 10", len(clock.deadlines))
+	}
+}
+
+func TestTimelineSkipsWhenBehind(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	tl := newFakeTimeline(clock)
+
+	// Действие дольше паузы: ждать нечего, расписание уже позади
+	clock.now = clock.now.Add(30 * time.Millisecond)
+	if err := tl.wait(context.Background(), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.deadlines) != 0 {
+		t.Fatalf("slept although the schedule is behind: %v", clock.deadlines)
+	}
+
+	// Следующая пауза возмещает опоздание и кончается по расписанию
+	if err := tl.wait(context.Background(), 40*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(50 * time.Millisecond); !clock.deadlines[0].Equal(want) {
+		t.Errorf("deadline = %v after start, want 50ms", clock.deadlines[0].Sub(start))
+	}
+}
+
+func TestTimelineLimitsDebt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	tl := newFakeTimeline(clock)
+
+	// После зависания на секунду паузы не пропадают все разом
+	clock.now = clock.now.Add(time.Second)
+	want := clock.now.Add(100*time.Millisecond - maxDebt)
+	if err := tl.wait(context.Background(), 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.deadlines) != 1 || !clock.deadlines[0].Equal(want) {
+		t.Errorf("deadlines = %v, want one pause shortened by maxDebt", clock.deadlines)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("measures real time")
+	}
+
+	report, err := Measure(context.Background(), 5*time.Millisecond, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Count != 40 || report.Requested != 5 {
+		t.Fatalf("report = %+v", report)
+	}
+	// Точность расписания проверяют тесты с подмененными часами, здесь только
+	// то, что не зависит от загрузки машины: серия не кончается раньше срока
+	if report.Drift < 0 || report.Mean < report.Requested {
+		t.Errorf("series finished early: %+v", report)
+	}
+}
+
+func TestTimelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	tl := newTimeline()
+	started := time.Now()
+	if err := tl.wait(ctx, time.Hour); err != context.Canceled {
+		t.Fatalf("wait() = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("wait returned after %v", elapsed)
+	}
+}
+
+// BenchmarkTimelineWait показывает среднюю ошибку одной паузы в 1 мс
+func BenchmarkTimelineWait(b *testing.B) {
+	report, err := Measure(context.Background(), time.Millisecond, b.N)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(report.Mean-report.Requested, "ms-error/op")
+	b.ReportMetric(report.MaxError, "ms-max-error")
+}
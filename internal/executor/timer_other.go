@@ -0,0 +1,8 @@
+//go:build !windows
+
+package executor
+
+// raiseTimerResolution на остальных системах не нужен, таймеры и так точные
+func raiseTimerResolution() func() {
+	return func() {}
+}
@@ -0,0 +1,18 @@
+package executor
+
+import "syscall"
+
+var (
+	winmm           = syscall.NewLazyDLL("winmm.dll")
+	timeBeginPeriod = winmm.NewProc("timeBeginPeriod")
+	timeEndPeriod   = winmm.NewProc("timeEndPeriod")
+)
+
+// raiseTimerResolution просит у системы таймер в 1 мс вместо стандартных 15.6,
+// вызовы считаются системой, так что вложенные сессии друг другу не мешают
+func raiseTimerResolution() func() {
+	timeBeginPeriod.Call(1)
+	return func() {
+		timeEndPeriod.Call(1)
+	}
+}
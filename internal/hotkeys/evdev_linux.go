@@ -0,0 +1,206 @@
+package hotkeys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"repeat-what-shit/internal/input"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	evKey = 0x01
+	evRel = 0x02
+
+	relWheel = 0x08
+
+	keyReleased = 0
+	keyPressed  = 1
+)
+
+// EVIOCGNAME(len) и EVIOCGBIT(0, len) из linux/input.h
+func eviocgname(size uintptr) uintptr { return 2<<30 | size<<16 | 'E'<<8 | 0x06 }
+func eviocgbit(size uintptr) uintptr  { return 2<<30 | size<<16 | 'E'<<8 | 0x20 }
+
+type evdevEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// EvdevSource читает все устройства ввода из /dev/input/event*.
+// Требует прав на чтение устройств (обычно группа input).
+type EvdevSource struct {
+	mu      sync.Mutex
+	devices []*os.File
+	done    chan struct{}
+}
+
+func NewEvdevSource() *EvdevSource {
+	return &EvdevSource{}
+}
+
+func (s *EvdevSource) Start(events chan<- Event) error {
+	paths, err := filepath.Glob("/dev/input/event*")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.done = make(chan struct{})
+
+	var openErr error
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			openErr = err
+			continue
+		}
+
+		if !isUsableDevice(file) {
+			file.Close()
+			continue
+		}
+
+		s.devices = append(s.devices, file)
+	}
+
+	if len(s.devices) == 0 {
+		if openErr != nil {
+			return fmt.Errorf("failed to open input devices: %w", openErr)
+		}
+		return errors.New("no input devices found")
+	}
+
+	for _, file := range s.devices {
+		go s.read(file, events)
+	}
+
+	return nil
+}
+
+func (s *EvdevSource) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+
+	for _, file := range s.devices {
+		file.Close()
+	}
+	s.devices = nil
+	return nil
+}
+
+func ioctl(file *os.File, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isUsableDevice отсеивает устройства без клавиш и наше собственное
+// виртуальное устройство, аналог проверки EMULATED_FLAG на Windows
+func isUsableDevice(file *os.File) bool {
+	var name [256]byte
+	if err := ioctl(file, eviocgname(uintptr(len(name))), unsafe.Pointer(&name[0])); err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(strings.TrimRight(string(name[:]), "\x00"), input.UinputDeviceName) {
+		return false
+	}
+
+	var bits [4]byte
+	if err := ioctl(file, eviocgbit(uintptr(len(bits))), unsafe.Pointer(&bits[0])); err != nil {
+		return false
+	}
+
+	return bits[0]&(1<<evKey) != 0
+}
+
+func (s *EvdevSource) read(file *os.File, events chan<- Event) {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+
+	size := int(unsafe.Sizeof(evdevEvent{}))
+	buf := make([]byte, size*64)
+
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+size <= n; offset += size {
+			ev := *(*evdevEvent)(unsafe.Pointer(&buf[offset]))
+
+			e, ok := translate(ev)
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- e:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+func translate(ev evdevEvent) (Event, bool) {
+	time := uint32(int64(ev.Time.Sec)*1000 + int64(ev.Time.Usec)/1000)
+
+	switch ev.Type {
+	case evKey:
+		// Автоповтор (value 2) не нужен, сервис и так помнит нажатые клавиши
+		if ev.Value != keyPressed && ev.Value != keyReleased {
+			return Event{}, false
+		}
+
+		// Кнопки мыши input переводит сразу в коды Code*Button
+		vk, ok := input.EvdevToVK(ev.Code)
+		if !ok {
+			return Event{}, false
+		}
+
+		if _, isButton := input.ButtonFromCode(vk); isButton {
+			kind := EventButtonUp
+			if ev.Value == keyPressed {
+				kind = EventButtonDown
+			}
+			return Event{Kind: kind, Code: vk, Time: time}, true
+		}
+
+		kind := EventKeyUp
+		if ev.Value == keyPressed {
+			kind = EventKeyDown
+		}
+		return Event{Kind: kind, Code: vk, Time: time}, true
+
+	case evRel:
+		if ev.Code != relWheel || ev.Value == 0 {
+			return Event{}, false
+		}
+
+		code := input.CodeWheelUp
+		if ev.Value < 0 {
+			code = input.CodeWheelDown
+		}
+		return Event{Kind: EventWheel, Code: code, Delta: int(ev.Value) * input.WheelDeltaStep, Time: time}, true
+	}
+
+	return Event{}, false
+}
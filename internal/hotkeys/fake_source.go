@@ -0,0 +1,53 @@
+package hotkeys
+
+import "sync"
+
+// FakeSource источник событий для тестов, события подаются вручную через Play
+type FakeSource struct {
+	mu     sync.Mutex
+	events chan<- Event
+	done   chan struct{}
+}
+
+func NewFakeSource() *FakeSource {
+	return &FakeSource{}
+}
+
+func (f *FakeSource) Start(events chan<- Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = events
+	f.done = make(chan struct{})
+	return nil
+}
+
+func (f *FakeSource) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done != nil {
+		close(f.done)
+	}
+	f.events = nil
+	f.done = nil
+	return nil
+}
+
+// Play отправляет события по порядку и ждет, пока сервис заберет каждое из них.
+// После Stop оставшиеся события отбрасываются.
+func (f *FakeSource) Play(events ...Event) {
+	f.mu.Lock()
+	ch, done := f.events, f.done
+	f.mu.Unlock()
+
+	if ch == nil {
+		return
+	}
+
+	for _, e := range events {
+		select {
+		case ch <- e:
+		case <-done:
+			return
+		}
+	}
+}
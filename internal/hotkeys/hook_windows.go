@@ -0,0 +1,112 @@
+package hotkeys
+
+import (
+	"repeat-what-shit/internal/input"
+
+	"github.com/moutend/go-hook/pkg/keyboard"
+	"github.com/moutend/go-hook/pkg/mouse"
+	"github.com/moutend/go-hook/pkg/types"
+)
+
+const (
+	WM_LBUTTONUP = 0x0202
+	WM_RBUTTONUP = 0x0205
+	WM_MBUTTONUP = 0x0208
+	WM_XBUTTONUP = 0x020C
+)
+
+// HookSource глобальные low level хуки клавиатуры и мыши через SetWindowsHookEx
+type HookSource struct {
+	keyboardChannel chan types.KeyboardEvent
+	mouseChannel    chan types.MouseEvent
+	done            chan struct{}
+}
+
+// NewBackends отдает SendInput и хук, свои события хук отсеивает по EMULATED_FLAG
+func NewBackends() (input.Injector, EventSource, error) {
+	injector, err := input.NewInjector()
+	if err != nil {
+		return nil, nil, err
+	}
+	return injector, &HookSource{}, nil
+}
+
+func (h *HookSource) Start(events chan<- Event) error {
+	h.keyboardChannel = make(chan types.KeyboardEvent)
+	h.mouseChannel = make(chan types.MouseEvent)
+	h.done = make(chan struct{})
+
+	if err := keyboard.Install(nil, h.keyboardChannel); err != nil {
+		return err
+	}
+
+	if err := mouse.Install(nil, h.mouseChannel); err != nil {
+		keyboard.Uninstall()
+		return err
+	}
+
+	go h.forward(events)
+	return nil
+}
+
+func (h *HookSource) Stop() error {
+	keyboard.Uninstall()
+	mouse.Uninstall()
+	close(h.done)
+	return nil
+}
+
+func (h *HookSource) emit(events chan<- Event, e Event) {
+	select {
+	case events <- e:
+	case <-h.done:
+	}
+}
+
+func (h *HookSource) forward(events chan<- Event) {
+	for {
+		select {
+		case <-h.done:
+			return
+
+		case e := <-h.keyboardChannel:
+			if uint32(e.DWExtraInfo) == input.EMULATED_FLAG {
+				continue
+			}
+
+			switch e.Message {
+			case types.WM_KEYDOWN, types.WM_SYSKEYDOWN:
+				h.emit(events, Event{Kind: EventKeyDown, Code: int(e.VKCode), Time: e.Time})
+			case types.WM_KEYUP, types.WM_SYSKEYUP:
+				h.emit(events, Event{Kind: EventKeyUp, Code: int(e.VKCode), Time: e.Time})
+			}
+
+		case e := <-h.mouseChannel:
+			if uint32(e.DWExtraInfo) == input.EMULATED_FLAG {
+				continue
+			}
+
+			switch e.Message {
+			case WM_MOUSEWHEEL:
+				delta := int(int16(e.MouseData >> 16))
+				code := int(e.Message)
+				if delta > 0 {
+					code |= 0x10000
+				} else {
+					code |= 0x20000
+				}
+				h.emit(events, Event{Kind: EventWheel, Code: code, Delta: delta, Time: e.Time})
+
+			case WM_LBUTTONDOWN, WM_RBUTTONDOWN, WM_MBUTTONDOWN:
+				h.emit(events, Event{Kind: EventButtonDown, Code: int(e.Message), Time: e.Time})
+			case WM_LBUTTONUP, WM_RBUTTONUP, WM_MBUTTONUP:
+				h.emit(events, Event{Kind: EventButtonUp, Code: int(e.Message) - 1, Time: e.Time})
+
+			case WM_XBUTTONDOWN:
+				h.emit(events, Event{Kind: EventButtonDown, Code: WM_XBUTTONDOWN | int(e.MouseData>>16)<<16, Time: e.Time})
+			case WM_XBUTTONUP:
+				h.emit(events, Event{Kind: EventButtonUp, Code: WM_XBUTTONDOWN | int(e.MouseData>>16)<<16, Time: e.Time})
+			}
+		}
+	}
+}
@@ -2,12 +2,8 @@ package hotkeys
 
 import (
 	"context"
-	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
 	"sync"
-
-	"github.com/moutend/go-hook/pkg/keyboard"
-	"github.com/moutend/go-hook/pkg/mouse"
-	"github.com/moutend/go-hook/pkg/types"
 )
 
 const (
@@ -19,53 +15,82 @@ const (
 )
 
 type KeyCombo struct {
-	Keys []int
+	Keys types.Combo
 	Time uint32
 }
 
 type KeyComboHandler func(combo KeyCombo)
 
+type EventHandler func(e Event)
+
 type HotkeyService struct {
-	keyboardChannel chan types.KeyboardEvent
-	mouseChannel    chan types.MouseEvent
-	handler         KeyComboHandler
-	pressedKeys     map[int]struct{}
-	cancelMu        sync.Mutex
-	cancelMap       map[int]context.CancelFunc
-	lastEventTime   uint32
+	source        EventSource
+	events        chan Event
+	runMu         sync.Mutex
+	done          chan struct{}
+	loop          sync.WaitGroup
+	handler       KeyComboHandler
+	eventHandler  EventHandler
+	pressedKeys   map[int]struct{}
+	cancelMu      sync.Mutex
+	cancelMap     map[int]context.CancelFunc
+	lastEventTime uint32
 }
 
-var globalService *HotkeyService
-
-func NewHotkeyService() *HotkeyService {
-	service := &HotkeyService{
-		keyboardChannel: make(chan types.KeyboardEvent),
-		mouseChannel:    make(chan types.MouseEvent),
-		pressedKeys:     make(map[int]struct{}),
-		cancelMap:       make(map[int]context.CancelFunc),
+func NewHotkeyService(source EventSource) *HotkeyService {
+	return &HotkeyService{
+		source:      source,
+		events:      make(chan Event),
+		pressedKeys: make(map[int]struct{}),
+		cancelMap:   make(map[int]context.CancelFunc),
 	}
-	globalService = service
-	return service
 }
 
 func (s *HotkeyService) Start(handler KeyComboHandler) error {
-	if err := keyboard.Install(nil, s.keyboardChannel); err != nil {
-		return err
-	}
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 
-	if err := mouse.Install(nil, s.mouseChannel); err != nil {
-		keyboard.Uninstall()
+	s.handler = handler
+	if err := s.source.Start(s.events); err != nil {
 		return err
 	}
 
-	s.handler = handler
-	go s.handleEvents()
+	s.done = make(chan struct{})
+	s.loop.Add(1)
+	go s.handleEvents(s.done)
 	return nil
 }
 
+// OnEvent подписывает на все события ввода до сборки их в комбинации.
+// Обработчик вызывается синхронно, поэтому должен быть быстрым.
+func (s *HotkeyService) OnEvent(handler EventHandler) {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+	s.eventHandler = handler
+}
+
+// Stop можно звать повторно и без успешного Start
 func (s *HotkeyService) Stop() {
-	keyboard.Uninstall()
-	mouse.Uninstall()
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	if s.done == nil {
+		return
+	}
+
+	s.source.Stop()
+	close(s.done)
+	s.done = nil
+	s.loop.Wait()
+
+	// Отпускания после остановки уже не придут, иначе клавиши залипнут до следующего Start
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+	for key, cancel := range s.cancelMap {
+		cancel()
+		delete(s.cancelMap, key)
+	}
+	clear(s.pressedKeys)
 }
 
 func (s *HotkeyService) isKeyPressed(key int) bool {
@@ -84,125 +109,88 @@ func (s *HotkeyService) cancelEmulation(key int) {
 	}
 }
 
-func (s *HotkeyService) handleEvents() {
+func (s *HotkeyService) handleEvents(done chan struct{}) {
+	defer s.loop.Done()
 	for {
 		select {
-		case e := <-s.keyboardChannel:
-			if uint32(e.DWExtraInfo) == input.EMULATED_FLAG {
-				continue
-			}
-
-			switch e.Message {
-			case types.WM_KEYDOWN, types.WM_SYSKEYDOWN:
-				key := int(e.VKCode)
-
-				if s.isKeyPressed(key) {
-					continue
-				}
-
-				s.cancelMu.Lock()
-				s.pressedKeys[key] = struct{}{}
-				s.lastEventTime = e.Time
-				s.cancelMu.Unlock()
-
-				_, cancel := context.WithCancel(context.Background())
-				s.cancelMu.Lock()
-				s.cancelMap[key] = cancel
-				s.cancelMu.Unlock()
-
-				if s.handler != nil {
-					combo := KeyCombo{
-						Keys: append([]int(nil), s.GetPressedKeys()...),
-						Time: e.Time,
-					}
-					go s.handler(combo)
-				}
-
-			case types.WM_KEYUP, types.WM_SYSKEYUP:
-				key := int(e.VKCode)
-
-				s.cancelEmulation(key)
-
-				s.cancelMu.Lock()
-				delete(s.pressedKeys, key)
-				s.lastEventTime = e.Time
-				s.cancelMu.Unlock()
-
-				if s.handler != nil {
-					combo := KeyCombo{
-						Keys: append([]int(nil), s.GetPressedKeys()...),
-						Time: e.Time,
-					}
-					go s.handler(combo)
-				}
-			}
-
-		case e := <-s.mouseChannel:
-			switch e.Message {
-			case WM_MOUSEWHEEL:
-				if s.handler != nil {
-					wheelEvent := int(e.Message)
-					if int16(e.MouseData>>16) > 0 {
-						wheelEvent |= 0x10000
-					} else {
-						wheelEvent |= 0x20000
-					}
-					combo := KeyCombo{
-						Keys: []int{wheelEvent},
-						Time: e.Time,
-					}
-					go s.handler(combo)
-				}
-			case WM_LBUTTONDOWN, WM_RBUTTONDOWN, WM_MBUTTONDOWN:
-				if s.handler != nil {
-					combo := KeyCombo{
-						Keys: []int{int(e.Message)},
-						Time: e.Time,
-					}
-					go s.handler(combo)
-				}
-			case WM_XBUTTONDOWN:
-				if s.handler != nil {
-					mouseEvent := int(e.Message) | int(e.MouseData>>16)<<16
-					combo := KeyCombo{
-						Keys: []int{mouseEvent},
-						Time: e.Time,
-					}
-					go s.handler(combo)
-				}
-			}
+		case <-done:
+			return
+		case e := <-s.events:
+			s.handleEvent(e)
 		}
 	}
 }
 
-func (s *HotkeyService) GetPressedKeys() []int {
+func (s *HotkeyService) handleEvent(e Event) {
+	// Автоповтор удерживаемой клавиши не доходит ни до записи, ни до хоткеев
+	if e.Kind == EventKeyDown && s.isKeyPressed(e.Code) {
+		return
+	}
+
 	s.cancelMu.Lock()
-	defer s.cancelMu.Unlock()
+	eventHandler := s.eventHandler
+	s.cancelMu.Unlock()
 
-	pressed := make([]int, 0, len(s.pressedKeys))
-	for key := range s.pressedKeys {
-		pressed = append(pressed, key)
+	if eventHandler != nil {
+		eventHandler(e)
+	}
+
+	switch e.Kind {
+	case EventKeyDown:
+		s.cancelMu.Lock()
+		s.pressedKeys[e.Code] = struct{}{}
+		s.lastEventTime = e.Time
+		s.cancelMu.Unlock()
+
+		_, cancel := context.WithCancel(context.Background())
+		s.cancelMu.Lock()
+		s.cancelMap[e.Code] = cancel
+		s.cancelMu.Unlock()
+
+		s.emit(KeyCombo{
+			Keys: s.GetPressedKeys(),
+			Time: e.Time,
+		})
+
+	case EventKeyUp:
+		s.cancelEmulation(e.Code)
+
+		s.cancelMu.Lock()
+		delete(s.pressedKeys, e.Code)
+		s.lastEventTime = e.Time
+		s.cancelMu.Unlock()
+
+		s.emit(KeyCombo{
+			Keys: s.GetPressedKeys(),
+			Time: e.Time,
+		})
+
+	case EventButtonDown, EventWheel:
+		s.emit(KeyCombo{
+			Keys: types.Combo{e.Code},
+			Time: e.Time,
+		})
 	}
-	return pressed
 }
 
-func IsComboPressed(combo []int) bool {
-	if globalService == nil {
-		return false
+func (s *HotkeyService) emit(combo KeyCombo) {
+	if s.handler != nil {
+		go s.handler(combo)
 	}
+}
 
-	pressed := globalService.GetPressedKeys()
-	for _, key := range combo {
-		found := false
-		for _, p := range pressed {
-			if p == key {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
+// GetPressedKeys возвращает нажатые клавиши в каноничной форме
+func (s *HotkeyService) GetPressedKeys() types.Combo {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	pressed := make(types.Combo, 0, len(s.pressedKeys))
+	for key := range s.pressedKeys {
+		pressed = append(pressed, key)
 	}
-	return true
+	return pressed.Canonical()
+}
+
+func (s *HotkeyService) IsComboPressed(combo types.Combo) bool {
+	return Holds(s.GetPressedKeys(), combo)
 }
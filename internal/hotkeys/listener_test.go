@@ -0,0 +1,188 @@
+package hotkeys
+
+import (
+	"repeat-what-shit/internal/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	vkA = 0x41
+	vkB = 0x42
+)
+
+// startService запускает сервис на FakeSource и отдает комбинации в канал
+func startService(t *testing.T) (*HotkeyService, *FakeSource, <-chan KeyCombo) {
+	t.Helper()
+	source := NewFakeSource()
+	service := NewHotkeyService(source)
+	combos := make(chan KeyCombo, 16)
+	if err := service.Start(func(combo KeyCombo) { combos <- combo }); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(service.Stop)
+	return service, source, combos
+}
+
+func nextCombo(t *testing.T, combos <-chan KeyCombo) KeyCombo {
+	t.Helper()
+	select {
+	case combo := <-combos:
+		return combo
+	case <-time.After(2 * time.Second):
+		t.Fatal("no combo emitted")
+		return KeyCombo{}
+	}
+}
+
+func TestPressedKeys(t *testing.T) {
+	service, source, combos := startService(t)
+
+	source.Play(Event{Kind: EventKeyDown, Code: VK_LCONTROL, Time: 1})
+	if got := nextCombo(t, combos); !got.Keys.Equal(types.Combo{VK_LCONTROL}) || got.Time != 1 {
+		t.Fatalf("combo after Ctrl down = %+v", got)
+	}
+
+	source.Play(Event{Kind: EventKeyDown, Code: vkA, Time: 2})
+	if got := nextCombo(t, combos); !got.Keys.Equal(types.Combo{vkA, VK_LCONTROL}) {
+		t.Fatalf("combo after A down = %v", got.Keys)
+	}
+	if !service.IsComboPressed(types.Combo{VK_CONTROL, vkA}) {
+		t.Error("generic Ctrl+A is not pressed")
+	}
+	if !service.IsComboPressed(types.Combo{vkA}) {
+		t.Error("A is not pressed")
+	}
+	if service.IsComboPressed(types.Combo{VK_RCONTROL, vkA}) {
+		t.Error("right Ctrl+A is pressed while left Ctrl is held")
+	}
+
+	source.Play(Event{Kind: EventKeyUp, Code: VK_LCONTROL, Time: 3})
+	if got := nextCombo(t, combos); !got.Keys.Equal(types.Combo{vkA}) {
+		t.Fatalf("combo after Ctrl up = %v", got.Keys)
+	}
+	if service.IsComboPressed(types.Combo{VK_CONTROL, vkA}) {
+		t.Error("Ctrl+A is pressed after Ctrl up")
+	}
+
+	source.Play(Event{Kind: EventKeyUp, Code: vkA, Time: 4})
+	if got := nextCombo(t, combos); len(got.Keys) != 0 {
+		t.Fatalf("combo after all keys up = %v", got.Keys)
+	}
+}
+
+func TestButtonAndWheelCombos(t *testing.T) {
+	service, source, combos := startService(t)
+
+	source.Play(Event{Kind: EventButtonDown, Code: WM_XBUTTONDOWN})
+	if got := nextCombo(t, combos); !got.Keys.Equal(types.Combo{WM_XBUTTONDOWN}) {
+		t.Fatalf("button combo = %v", got.Keys)
+	}
+	source.Play(Event{Kind: EventWheel, Code: WM_MOUSEWHEEL, Delta: 120})
+	if got := nextCombo(t, combos); !got.Keys.Equal(types.Combo{WM_MOUSEWHEEL}) {
+		t.Fatalf("wheel combo = %v", got.Keys)
+	}
+	if len(service.GetPressedKeys()) != 0 {
+		t.Errorf("buttons are tracked as pressed keys: %v", service.GetPressedKeys())
+	}
+}
+
+func TestAutoRepeatIsDropped(t *testing.T) {
+	service, source, combos := startService(t)
+
+	var mu sync.Mutex
+	var seen []Event
+	service.OnEvent(func(e Event) {
+		mu.Lock()
+		seen = append(seen, e)
+		mu.Unlock()
+	})
+
+	source.Play(
+		Event{Kind: EventKeyDown, Code: vkA, Time: 1},
+		Event{Kind: EventKeyDown, Code: vkA, Time: 2},
+		Event{Kind: EventKeyDown, Code: vkA, Time: 3},
+		Event{Kind: EventKeyUp, Code: vkA, Time: 4},
+	)
+	nextCombo(t, combos)
+	nextCombo(t, combos)
+
+	select {
+	case combo := <-combos:
+		t.Fatalf("auto-repeat emitted combo %v", combo.Keys)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []EventKind{EventKeyDown, EventKeyUp}
+	if len(seen) != len(want) {
+		t.Fatalf("OnEvent saw %d events, want %d: %+v", len(seen), len(want), seen)
+	}
+	for i, e := range seen {
+		if e.Kind != want[i] {
+			t.Errorf("event %d kind = %v, want %v", i, e.Kind, want[i])
+		}
+	}
+}
+
+func TestStopClearsPressedKeys(t *testing.T) {
+	service, source, combos := startService(t)
+
+	source.Play(Event{Kind: EventKeyDown, Code: vkA}, Event{Kind: EventKeyDown, Code: vkB})
+	nextCombo(t, combos)
+	nextCombo(t, combos)
+
+	service.Stop()
+	if keys := service.GetPressedKeys(); len(keys) != 0 {
+		t.Fatalf("pressed keys after Stop = %v", keys)
+	}
+
+	// Play после Stop не должен зависать
+	played := make(chan struct{})
+	go func() {
+		source.Play(Event{Kind: EventKeyDown, Code: vkA})
+		close(played)
+	}()
+	select {
+	case <-played:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Play blocks after Stop")
+	}
+
+	if err := service.Start(func(combo KeyCombo) {}); err != nil {
+		t.Fatalf("restart error: %v", err)
+	}
+	if service.IsComboPressed(types.Combo{vkA}) {
+		t.Error("key pressed before Stop survived the restart")
+	}
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	service := NewHotkeyService(NewFakeSource())
+	service.Stop()
+	service.Stop()
+}
+
+func TestPlayUnblocksOnStop(t *testing.T) {
+	source := NewFakeSource()
+	// Сервис не читает из канала, так что Play ждет, пока источник не остановят
+	if err := source.Start(make(chan Event)); err != nil {
+		t.Fatal(err)
+	}
+
+	played := make(chan struct{})
+	go func() {
+		source.Play(Event{Kind: EventKeyDown, Code: vkA})
+		close(played)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	source.Stop()
+	select {
+	case <-played:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Play did not return after Stop")
+	}
+}
@@ -0,0 +1,49 @@
+package hotkeys
+
+import "repeat-what-shit/internal/types"
+
+const (
+	VK_SHIFT    = 0x10
+	VK_CONTROL  = 0x11
+	VK_MENU     = 0x12
+	VK_LSHIFT   = 0xA0
+	VK_RSHIFT   = 0xA1
+	VK_LCONTROL = 0xA2
+	VK_RCONTROL = 0xA3
+	VK_LMENU    = 0xA4
+	VK_RMENU    = 0xA5
+)
+
+// Хук сообщает только коды с указанием стороны, а в макросе может быть общий модификатор
+var genericModifiers = map[int]int{
+	VK_LSHIFT:   VK_SHIFT,
+	VK_RSHIFT:   VK_SHIFT,
+	VK_LCONTROL: VK_CONTROL,
+	VK_RCONTROL: VK_CONTROL,
+	VK_LMENU:    VK_MENU,
+	VK_RMENU:    VK_MENU,
+}
+
+// Normalize заменяет в pressed модификаторы с указанием стороны на общие,
+// если activation содержит общий модификатор, а не конкретную сторону
+func Normalize(pressed, activation types.Combo) types.Combo {
+	normalized := make(types.Combo, 0, len(pressed))
+	for _, key := range pressed {
+		generic, ok := genericModifiers[key]
+		if ok && !activation.Contains(types.Combo{key}) && activation.Contains(types.Combo{generic}) {
+			key = generic
+		}
+		normalized = append(normalized, key)
+	}
+	return normalized.Canonical()
+}
+
+// Match сообщает, что нажата ровно комбинация activation
+func Match(pressed, activation types.Combo) bool {
+	return Normalize(pressed, activation).String() == activation.String()
+}
+
+// Holds сообщает, что среди pressed есть все клавиши activation
+func Holds(pressed, activation types.Combo) bool {
+	return Normalize(pressed, activation).Contains(activation)
+}
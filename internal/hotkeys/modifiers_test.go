@@ -0,0 +1,84 @@
+package hotkeys
+
+import (
+	"repeat-what-shit/internal/types"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		pressed    types.Combo
+		activation types.Combo
+		want       types.Combo
+	}{
+		{"generic activation", types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_CONTROL, vkA}, types.Combo{VK_CONTROL, vkA}},
+		{"right side to generic", types.Combo{VK_RSHIFT}, types.Combo{VK_SHIFT}, types.Combo{VK_SHIFT}},
+		{"specific side kept", types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_LCONTROL, vkA}},
+		{"other side kept", types.Combo{VK_RCONTROL, vkA}, types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_RCONTROL, vkA}},
+		{"both sides collapse", types.Combo{VK_LMENU, VK_RMENU}, types.Combo{VK_MENU}, types.Combo{VK_MENU}},
+		{"activation has generic and side", types.Combo{VK_LSHIFT, VK_RSHIFT}, types.Combo{VK_SHIFT, VK_RSHIFT}, types.Combo{VK_SHIFT, VK_RSHIFT}},
+		{"unrelated modifier", types.Combo{VK_LMENU, vkA}, types.Combo{VK_CONTROL, vkA}, types.Combo{vkA, VK_LMENU}},
+		{"empty", nil, types.Combo{VK_CONTROL}, types.Combo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.pressed, tt.activation); !got.Equal(tt.want) {
+				t.Errorf("Normalize(%v, %v) = %v, want %v", tt.pressed, tt.activation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		pressed    types.Combo
+		activation types.Combo
+		want       bool
+	}{
+		{"generic matches left", types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_CONTROL, vkA}, true},
+		{"generic matches right", types.Combo{VK_RCONTROL, vkA}, types.Combo{VK_CONTROL, vkA}, true},
+		{"left matches left", types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_LCONTROL, vkA}, true},
+		{"left does not match right", types.Combo{VK_RCONTROL, vkA}, types.Combo{VK_LCONTROL, vkA}, false},
+		{"right does not match left", types.Combo{VK_LSHIFT}, types.Combo{VK_RSHIFT}, false},
+		{"extra key", types.Combo{VK_LCONTROL, VK_LSHIFT, vkA}, types.Combo{VK_CONTROL, vkA}, false},
+		{"missing key", types.Combo{VK_LCONTROL}, types.Combo{VK_CONTROL, vkA}, false},
+		{"order does not matter", types.Combo{vkA, VK_LMENU}, types.Combo{VK_MENU, vkA}, true},
+		{"plain key", types.Combo{vkA}, types.Combo{vkA}, true},
+		{"modifier alone is not a key", types.Combo{VK_LCONTROL}, types.Combo{vkA}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.pressed, tt.activation); got != tt.want {
+				t.Errorf("Match(%v, %v) = %v, want %v", tt.pressed, tt.activation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHolds(t *testing.T) {
+	tests := []struct {
+		name       string
+		pressed    types.Combo
+		activation types.Combo
+		want       bool
+	}{
+		{"exact", types.Combo{VK_LCONTROL, vkA}, types.Combo{VK_CONTROL, vkA}, true},
+		{"extra keys held", types.Combo{VK_LCONTROL, VK_LSHIFT, vkA, vkB}, types.Combo{VK_CONTROL, vkA}, true},
+		{"specific side held", types.Combo{VK_RMENU, vkA}, types.Combo{VK_RMENU}, true},
+		{"wrong side held", types.Combo{VK_LMENU, vkA}, types.Combo{VK_RMENU}, false},
+		{"key released", types.Combo{VK_LCONTROL}, types.Combo{VK_CONTROL, vkA}, false},
+		{"nothing held", nil, types.Combo{vkA}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Holds(tt.pressed, tt.activation); got != tt.want {
+				t.Errorf("Holds(%v, %v) = %v, want %v", tt.pressed, tt.activation, got, tt.want)
+			}
+		})
+	}
+}
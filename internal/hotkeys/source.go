@@ -0,0 +1,31 @@
+package hotkeys
+
+import "errors"
+
+var ErrUnsupported = errors.New("global hotkeys are not supported on this platform")
+
+type EventKind int
+
+const (
+	EventKeyDown EventKind = iota
+	EventKeyUp
+	EventButtonDown
+	EventButtonUp
+	EventWheel
+)
+
+// Event нормализованное событие ввода. Code это VK код для клавиатуры
+// или код кнопки мыши в том же виде, в каком он хранится в ActivationKeys.
+type Event struct {
+	Kind  EventKind
+	Code  int
+	Delta int
+	Time  uint32
+}
+
+// EventSource поставляет события ввода в HotkeyService.
+// Эмулированные нами события источник должен отбрасывать сам.
+type EventSource interface {
+	Start(events chan<- Event) error
+	Stop() error
+}
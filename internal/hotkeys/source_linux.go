@@ -0,0 +1,51 @@
+package hotkeys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"repeat-what-shit/internal/input"
+)
+
+// NewBackends подбирает отправку и прослушку ввода парой: uinput с evdev или
+// XTEST с XRecord. Смешивать нельзя: XRecord видит вывод uinput как настоящий
+// ввод и отличить его не может, а макросы начнут запускать сами себя.
+func NewBackends() (input.Injector, EventSource, error) {
+	var uinputErr error
+	if evdevAvailable() {
+		injector, err := input.NewUinputInjector()
+		if err == nil {
+			return injector, NewEvdevSource(), nil
+		}
+		uinputErr = err
+	}
+
+	if os.Getenv("DISPLAY") == "" {
+		if uinputErr == nil {
+			uinputErr = errors.New("no readable input devices in /dev/input and no X server")
+		}
+		return nil, nil, uinputErr
+	}
+
+	injector, err := input.NewX11Injector("")
+	if err != nil {
+		return nil, nil, err
+	}
+	return injector, NewX11Source(""), nil
+}
+
+func evdevAvailable() bool {
+	paths, _ := filepath.Glob("/dev/input/event*")
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		usable := isUsableDevice(file)
+		file.Close()
+		if usable {
+			return true
+		}
+	}
+	return false
+}
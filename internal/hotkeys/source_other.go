@@ -0,0 +1,9 @@
+//go:build !windows && !linux
+
+package hotkeys
+
+import "repeat-what-shit/internal/input"
+
+func NewBackends() (input.Injector, EventSource, error) {
+	return nil, nil, ErrUnsupported
+}
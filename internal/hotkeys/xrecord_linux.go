@@ -0,0 +1,342 @@
+package hotkeys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"repeat-what-shit/internal/input"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/record"
+	"github.com/jezek/xgb/xproto"
+)
+
+const (
+	recordEnableContext = 5
+
+	recordFromServer  = 0
+	recordEndOfData   = 5
+	x11ReplyHeaderLen = 32
+	x11EventLen       = 32
+)
+
+// X11Source слушает ввод через расширение RECORD. xgb не умеет читать
+// поток ответов EnableContext, поэтому для данных открывается отдельное
+// сырое соединение, а контекст создается через обычное.
+type X11Source struct {
+	display string
+
+	mu      sync.Mutex
+	ctrl    *xgb.Conn
+	data    net.Conn
+	context record.Context
+	done    chan struct{}
+}
+
+// NewX11Source слушает дисплей display, пустая строка значит $DISPLAY
+func NewX11Source(display string) *X11Source {
+	return &X11Source{display: display}
+}
+
+func (s *X11Source) Start(events chan<- Event) error {
+	ctrl, err := xgb.NewConnDisplay(s.display)
+	if err != nil {
+		return fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	if err := record.Init(ctrl); err != nil {
+		ctrl.Close()
+		return fmt.Errorf("RECORD extension is not available: %w", err)
+	}
+
+	ctrl.ExtLock.RLock()
+	opcode := ctrl.Extensions["RECORD"]
+	ctrl.ExtLock.RUnlock()
+
+	context, err := record.NewContextId(ctrl)
+	if err != nil {
+		ctrl.Close()
+		return err
+	}
+
+	ranges := []record.Range{{
+		DeviceEvents: record.Range8{First: xproto.KeyPress, Last: xproto.ButtonRelease},
+	}}
+	clients := []record.ClientSpec{record.CsAllClients}
+
+	if err := record.CreateContextChecked(ctrl, context, 0, uint32(len(clients)), uint32(len(ranges)), clients, ranges).Check(); err != nil {
+		ctrl.Close()
+		return fmt.Errorf("failed to create record context: %w", err)
+	}
+
+	data, err := dialX11(s.display)
+	if err != nil {
+		record.FreeContext(ctrl, context)
+		ctrl.Close()
+		return err
+	}
+
+	request := make([]byte, 8)
+	request[0] = opcode
+	request[1] = recordEnableContext
+	binary.LittleEndian.PutUint16(request[2:], 2)
+	binary.LittleEndian.PutUint32(request[4:], uint32(context))
+
+	if _, err := data.Write(request); err != nil {
+		data.Close()
+		record.FreeContext(ctrl, context)
+		ctrl.Close()
+		return fmt.Errorf("failed to enable record context: %w", err)
+	}
+
+	s.mu.Lock()
+	s.ctrl = ctrl
+	s.data = data
+	s.context = context
+	s.done = make(chan struct{})
+	done := s.done
+	s.mu.Unlock()
+
+	go s.read(data, events, done)
+	return nil
+}
+
+func (s *X11Source) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctrl == nil {
+		return nil
+	}
+
+	close(s.done)
+	record.DisableContextChecked(s.ctrl, s.context).Check()
+	record.FreeContext(s.ctrl, s.context)
+	s.data.Close()
+	s.ctrl.Close()
+	s.ctrl = nil
+	s.data = nil
+	return nil
+}
+
+func (s *X11Source) read(data net.Conn, events chan<- Event, done chan struct{}) {
+	header := make([]byte, x11ReplyHeaderLen)
+
+	for {
+		if _, err := io.ReadFull(data, header); err != nil {
+			return
+		}
+
+		// Все, что не ответ (ошибки, события), имеет фиксированную длину 32 байта
+		if header[0] != 1 {
+			continue
+		}
+
+		body := make([]byte, int(binary.LittleEndian.Uint32(header[4:]))*4)
+		if _, err := io.ReadFull(data, body); err != nil {
+			return
+		}
+
+		switch header[1] {
+		case recordEndOfData:
+			return
+		case recordFromServer:
+		default:
+			continue
+		}
+
+		for offset := 0; offset+x11EventLen <= len(body); offset += x11EventLen {
+			e, ok := translateX11(body[offset : offset+x11EventLen])
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- e:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+func translateX11(raw []byte) (Event, bool) {
+	eventType := raw[0] & 0x7f
+	detail := raw[1]
+	time := binary.LittleEndian.Uint32(raw[4:])
+
+	if input.IsX11Echo(eventType, detail) {
+		return Event{}, false
+	}
+
+	switch eventType {
+	case xproto.KeyPress, xproto.KeyRelease:
+		if detail < input.X11KeycodeOffset {
+			return Event{}, false
+		}
+
+		vk, ok := input.EvdevToVK(uint16(detail) - input.X11KeycodeOffset)
+		if !ok {
+			return Event{}, false
+		}
+
+		kind := EventKeyUp
+		if eventType == xproto.KeyPress {
+			kind = EventKeyDown
+		}
+		return Event{Kind: kind, Code: vk, Time: time}, true
+
+	case xproto.ButtonPress, xproto.ButtonRelease:
+		// Для колеса достаточно нажатия
+		if detail == input.X11WheelUp || detail == input.X11WheelDown {
+			if eventType != xproto.ButtonPress {
+				return Event{}, false
+			}
+			if detail == input.X11WheelUp {
+				return Event{Kind: EventWheel, Code: input.CodeWheelUp, Delta: input.WheelDeltaStep, Time: time}, true
+			}
+			return Event{Kind: EventWheel, Code: input.CodeWheelDown, Delta: -input.WheelDeltaStep, Time: time}, true
+		}
+
+		code, ok := input.X11ButtonCode(detail)
+		if !ok {
+			return Event{}, false
+		}
+
+		kind := EventButtonUp
+		if eventType == xproto.ButtonPress {
+			kind = EventButtonDown
+		}
+		return Event{Kind: kind, Code: code, Time: time}, true
+	}
+
+	return Event{}, false
+}
+
+// dialX11 открывает соединение с X сервером и проходит рукопожатие
+// с MIT-MAGIC-COOKIE-1 из $XAUTHORITY, как это делает xgb
+func dialX11(display string) (net.Conn, error) {
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return nil, errors.New("bad display string: " + display)
+	}
+
+	host := display[:colon]
+	number := display[colon+1:]
+	if dot := strings.Index(number, "."); dot >= 0 {
+		number = number[:dot]
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, errors.New("bad display string: " + display)
+	}
+
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		conn, err = net.Dial("unix", "/tmp/.X11-unix/X"+number)
+	} else {
+		conn, err = net.Dial("tcp", host+":"+strconv.Itoa(6000+n))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", display, err)
+	}
+
+	authName, authData := readXAuthority(number)
+
+	setup := make([]byte, 12+xgb.Pad(len(authName))+xgb.Pad(len(authData)))
+	setup[0] = 'l'
+	binary.LittleEndian.PutUint16(setup[2:], 11)
+	binary.LittleEndian.PutUint16(setup[6:], uint16(len(authName)))
+	binary.LittleEndian.PutUint16(setup[8:], uint16(len(authData)))
+	copy(setup[12:], authName)
+	copy(setup[12+xgb.Pad(len(authName)):], authData)
+
+	if _, err := conn.Write(setup); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	rest := make([]byte, int(binary.LittleEndian.Uint16(head[6:]))*4)
+	if _, err := io.ReadFull(conn, rest); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if head[0] != 1 {
+		conn.Close()
+		return nil, fmt.Errorf("x protocol authentication refused: %s", rest[:min(int(head[1]), len(rest))])
+	}
+
+	return conn, nil
+}
+
+// readXAuthority ищет cookie для локального дисплея, при ошибке
+// пробуем подключиться без авторизации
+func readXAuthority(display string) (string, []byte) {
+	const familyLocal = 256
+	const familyWild = 65535
+
+	hostname, _ := os.Hostname()
+
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		path = os.Getenv("HOME") + "/.Xauthority"
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+
+	next := func() ([]byte, bool) {
+		if len(raw) < 2 {
+			return nil, false
+		}
+		size := int(binary.BigEndian.Uint16(raw))
+		if len(raw) < 2+size {
+			return nil, false
+		}
+		field := raw[2 : 2+size]
+		raw = raw[2+size:]
+		return field, true
+	}
+
+	for len(raw) >= 2 {
+		family := binary.BigEndian.Uint16(raw)
+		raw = raw[2:]
+
+		addr, ok1 := next()
+		disp, ok2 := next()
+		name, ok3 := next()
+		data, ok4 := next()
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return "", nil
+		}
+
+		addrMatch := family == familyWild || (family == familyLocal && string(addr) == hostname)
+		dispMatch := len(disp) == 0 || string(disp) == display
+
+		if addrMatch && dispMatch {
+			return string(name), data
+		}
+	}
+
+	return "", nil
+}
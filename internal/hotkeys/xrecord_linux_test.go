@@ -0,0 +1,125 @@
+package hotkeys
+
+import (
+	"encoding/binary"
+	"os"
+	"repeat-what-shit/internal/input"
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+	"github.com/jezek/xgb/xtest"
+)
+
+func rawX11Event(eventType, detail byte, time uint32) []byte {
+	raw := make([]byte, x11EventLen)
+	raw[0] = eventType
+	raw[1] = detail
+	binary.LittleEndian.PutUint32(raw[4:], time)
+	return raw
+}
+
+func TestTranslateX11(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType byte
+		detail    byte
+		want      Event
+		ok        bool
+	}{
+		{"key down", xproto.KeyPress, 30 + input.X11KeycodeOffset, Event{Kind: EventKeyDown, Code: 0x41, Time: 7}, true},
+		{"key up", xproto.KeyRelease, 30 + input.X11KeycodeOffset, Event{Kind: EventKeyUp, Code: 0x41, Time: 7}, true},
+		{"keycode below offset", xproto.KeyPress, 3, Event{}, false},
+		{"left button", xproto.ButtonPress, 1, Event{Kind: EventButtonDown, Code: input.CodeLButton, Time: 7}, true},
+		{"x2 button up", xproto.ButtonRelease, 9, Event{Kind: EventButtonUp, Code: input.CodeXButton2, Time: 7}, true},
+		{"wheel up", xproto.ButtonPress, input.X11WheelUp, Event{Kind: EventWheel, Code: input.CodeWheelUp, Delta: input.WheelDeltaStep, Time: 7}, true},
+		{"wheel down", xproto.ButtonPress, input.X11WheelDown, Event{Kind: EventWheel, Code: input.CodeWheelDown, Delta: -input.WheelDeltaStep, Time: 7}, true},
+		{"wheel release", xproto.ButtonRelease, input.X11WheelUp, Event{}, false},
+		{"motion", xproto.MotionNotify, 0, Event{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := translateX11(rawX11Event(tt.eventType, tt.detail, 7))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("translateX11() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// Дальше нужен настоящий X сервер, например xvfb-run go test ./...
+func startTestX11Source(t *testing.T) chan Event {
+	t.Helper()
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set")
+	}
+
+	events := make(chan Event, 16)
+	source := NewX11Source("")
+	if err := source.Start(events); err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { source.Stop() })
+	return events
+}
+
+func waitX11Event(events chan Event, timeout time.Duration) (Event, bool) {
+	select {
+	case e := <-events:
+		return e, true
+	case <-time.After(timeout):
+		return Event{}, false
+	}
+}
+
+func TestX11SourceReceivesForeignInput(t *testing.T) {
+	events := startTestX11Source(t)
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := xtest.Init(conn); err != nil {
+		t.Skip(err)
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	// В обход X11Injector, чтобы событие не считалось нашим эхом
+	keycode := byte(30 + input.X11KeycodeOffset)
+	for _, eventType := range []byte{xproto.KeyPress, xproto.KeyRelease} {
+		if err := xtest.FakeInputChecked(conn, eventType, keycode, 0, root, 0, 0, 0).Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []EventKind{EventKeyDown, EventKeyUp} {
+		e, ok := waitX11Event(events, 2*time.Second)
+		if !ok {
+			t.Fatalf("no event, want kind %d", want)
+		}
+		if e.Kind != want || e.Code != 0x41 {
+			t.Fatalf("got %+v, want kind %d for code 0x41", e, want)
+		}
+	}
+}
+
+func TestX11SourceSkipsInjectedInput(t *testing.T) {
+	events := startTestX11Source(t)
+
+	injector, err := input.NewX11Injector("")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer injector.Close()
+
+	if err := injector.Tap([]int{0x41}); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, ok := waitX11Event(events, 300*time.Millisecond); ok {
+		t.Fatalf("injected input came back as %+v", e)
+	}
+}
@@ -0,0 +1,68 @@
+package input
+
+import "time"
+
+// EMULATED_FLAG помечает события, отправленные нами, чтобы хук их пропускал
+const EMULATED_FLAG = 0xBADF00D
+
+const tapDelay = 10 * time.Millisecond
+
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseRight
+	MouseMiddle
+	MouseX1
+	MouseX2
+)
+
+// Injector отправляет синтетический ввод в систему
+type Injector interface {
+	KeyDown(key int) error
+	KeyUp(key int) error
+	// Tap нажимает все клавиши разом и затем отпускает их
+	Tap(keys []int) error
+	Mouse(button MouseButton, down bool) error
+	Wheel(delta int) error
+	Move(dx, dy int) error
+	// MoveTo перемещает курсор в точку виртуального рабочего стола (все мониторы)
+	MoveTo(x, y int) error
+	// TypeRune печатает символ независимо от текущей раскладки
+	TypeRune(r rune) error
+}
+
+// Click нажимает и отпускает кнопку мыши с той же паузой, что и Tap
+func Click(injector Injector, button MouseButton) error {
+	if err := injector.Mouse(button, true); err != nil {
+		return err
+	}
+	time.Sleep(tapDelay)
+	return injector.Mouse(button, false)
+}
+
+var buttonCodes = map[MouseButton]int{
+	MouseLeft:   CodeLButton,
+	MouseRight:  CodeRButton,
+	MouseMiddle: CodeMButton,
+	MouseX1:     CodeXButton1,
+	MouseX2:     CodeXButton2,
+}
+
+// ButtonFromCode распознает кнопки мыши среди кодов клавиш: VK_LBUTTON и т.п.
+// или коды WM_*, которые фронт сохраняет для кнопок мыши
+func ButtonFromCode(code int) (MouseButton, bool) {
+	switch code {
+	case 0x01, CodeLButton:
+		return MouseLeft, true
+	case 0x02, CodeRButton:
+		return MouseRight, true
+	case 0x04, CodeMButton:
+		return MouseMiddle, true
+	case 0x05, CodeXButton1:
+		return MouseX1, true
+	case 0x06, CodeXButton2:
+		return MouseX2, true
+	}
+	return 0, false
+}
@@ -0,0 +1,282 @@
+package input
+
+import (
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	user32           = syscall.NewLazyDLL("user32.dll")
+	sendInput        = user32.NewProc("SendInput")
+	getSystemMetrics = user32.NewProc("GetSystemMetrics")
+)
+
+const (
+	INPUT_KEYBOARD = 1
+	INPUT_MOUSE    = 0
+
+	KEYEVENTF_EXTENDEDKEY = 0x0001
+	KEYEVENTF_KEYUP       = 0x0002
+	KEYEVENTF_UNICODE     = 0x0004
+	KEYEVENTF_SCANCODE    = 0x0008
+
+	VK_TAB    = 0x09
+	VK_RETURN = 0x0D
+
+	MOUSEEVENTF_MOVE        = 0x0001
+	MOUSEEVENTF_LEFTDOWN    = 0x0002
+	MOUSEEVENTF_LEFTUP      = 0x0004
+	MOUSEEVENTF_RIGHTDOWN   = 0x0008
+	MOUSEEVENTF_RIGHTUP     = 0x0010
+	MOUSEEVENTF_MIDDLEDOWN  = 0x0020
+	MOUSEEVENTF_MIDDLEUP    = 0x0040
+	MOUSEEVENTF_XDOWN       = 0x0080
+	MOUSEEVENTF_XUP         = 0x0100
+	MOUSEEVENTF_WHEEL       = 0x0800
+	MOUSEEVENTF_VIRTUALDESK = 0x4000
+	MOUSEEVENTF_ABSOLUTE    = 0x8000
+
+	XBUTTON1 = 0x0001
+	XBUTTON2 = 0x0002
+
+	SM_XVIRTUALSCREEN  = 76
+	SM_YVIRTUALSCREEN  = 77
+	SM_CXVIRTUALSCREEN = 78
+	SM_CYVIRTUALSCREEN = 79
+)
+
+type INPUT struct {
+	Type uint32
+	Ki   struct {
+		Vk        uint16
+		Scan      uint16
+		Flags     uint32
+		Time      uint32
+		ExtraInfo uintptr
+		Padding1  uint32
+		Padding2  uint32
+	}
+}
+
+// MOUSE_INPUT тот же INPUT, но с мышиным членом union
+type MOUSE_INPUT struct {
+	Type uint32
+	Mi   struct {
+		Dx        int32
+		Dy        int32
+		MouseData uint32
+		Flags     uint32
+		Time      uint32
+		ExtraInfo uintptr
+	}
+}
+
+type Win32Injector struct {
+	mode Mode
+}
+
+func NewInjector() (Injector, error) {
+	return &Win32Injector{}, nil
+}
+
+func send[T INPUT | MOUSE_INPUT](inputs []T) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	ret, _, err := sendInput.Call(
+		uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])),
+		unsafe.Sizeof(inputs[0]),
+	)
+
+	if ret == 0 {
+		return err
+	}
+
+	return nil
+}
+
+func (w *Win32Injector) WithMode(mode Mode) Injector {
+	return &Win32Injector{mode: mode}
+}
+
+func (w *Win32Injector) keyInputs(keys []int, flags uint32) []INPUT {
+	inputs := make([]INPUT, len(keys))
+	for i, key := range keys {
+		inputs[i].Type = INPUT_KEYBOARD
+		inputs[i].Ki.Flags = flags
+		inputs[i].Ki.ExtraInfo = EMULATED_FLAG
+
+		if w.mode == ModeScancode {
+			if code, extended, ok := Scancode(key); ok {
+				inputs[i].Ki.Scan = code
+				inputs[i].Ki.Flags |= KEYEVENTF_SCANCODE
+				if extended {
+					inputs[i].Ki.Flags |= KEYEVENTF_EXTENDEDKEY
+				}
+				continue
+			}
+		}
+
+		inputs[i].Ki.Vk = uint16(key)
+	}
+	return inputs
+}
+
+func mouseInput(flags uint32, data uint32, dx, dy int32) MOUSE_INPUT {
+	var in MOUSE_INPUT
+	in.Type = INPUT_MOUSE
+	in.Mi.Dx = dx
+	in.Mi.Dy = dy
+	in.Mi.MouseData = data
+	in.Mi.Flags = flags
+	in.Mi.ExtraInfo = EMULATED_FLAG
+	return in
+}
+
+// keys отправляет клавиши одной пачкой, а кнопки мыши и колесо,
+// которые фронт позволяет указать среди клавиш, отдельными событиями
+func (w *Win32Injector) keys(keys []int, down bool) error {
+	var keyboard []int
+	for _, key := range keys {
+		if button, ok := ButtonFromCode(key); ok {
+			if err := w.Mouse(button, down); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if ticks, ok := wheelTicks(key); ok {
+			if down {
+				if err := w.Wheel(ticks * WheelDeltaStep); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		keyboard = append(keyboard, key)
+	}
+
+	var flags uint32
+	if !down {
+		flags = KEYEVENTF_KEYUP
+	}
+	return send(w.keyInputs(keyboard, flags))
+}
+
+func (w *Win32Injector) KeyDown(key int) error {
+	return w.keys([]int{key}, true)
+}
+
+func (w *Win32Injector) KeyUp(key int) error {
+	return w.keys([]int{key}, false)
+}
+
+func (w *Win32Injector) Tap(keys []int) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := w.keys(keys, true); err != nil {
+		return err
+	}
+
+	time.Sleep(tapDelay)
+
+	return w.keys(keys, false)
+}
+
+func (w *Win32Injector) Mouse(button MouseButton, down bool) error {
+	var flags, data uint32
+
+	switch button {
+	case MouseLeft:
+		flags = MOUSEEVENTF_LEFTUP
+		if down {
+			flags = MOUSEEVENTF_LEFTDOWN
+		}
+	case MouseRight:
+		flags = MOUSEEVENTF_RIGHTUP
+		if down {
+			flags = MOUSEEVENTF_RIGHTDOWN
+		}
+	case MouseMiddle:
+		flags = MOUSEEVENTF_MIDDLEUP
+		if down {
+			flags = MOUSEEVENTF_MIDDLEDOWN
+		}
+	case MouseX1, MouseX2:
+		flags = MOUSEEVENTF_XUP
+		if down {
+			flags = MOUSEEVENTF_XDOWN
+		}
+		data = XBUTTON1
+		if button == MouseX2 {
+			data = XBUTTON2
+		}
+	default:
+		return nil
+	}
+
+	return send([]MOUSE_INPUT{mouseInput(flags, data, 0, 0)})
+}
+
+func (w *Win32Injector) Wheel(delta int) error {
+	return send([]MOUSE_INPUT{mouseInput(MOUSEEVENTF_WHEEL, uint32(int32(delta)), 0, 0)})
+}
+
+func (w *Win32Injector) Move(dx, dy int) error {
+	return send([]MOUSE_INPUT{mouseInput(MOUSEEVENTF_MOVE, 0, int32(dx), int32(dy))})
+}
+
+func systemMetric(index uintptr) int {
+	ret, _, _ := getSystemMetrics.Call(index)
+	return int(int32(ret))
+}
+
+func (w *Win32Injector) MoveTo(x, y int) error {
+	left := systemMetric(SM_XVIRTUALSCREEN)
+	top := systemMetric(SM_YVIRTUALSCREEN)
+	width := systemMetric(SM_CXVIRTUALSCREEN)
+	height := systemMetric(SM_CYVIRTUALSCREEN)
+
+	if width <= 1 || height <= 1 {
+		return nil
+	}
+
+	// Абсолютные координаты SendInput нормализованы в диапазон 0..65535
+	dx := int32((x - left) * 65535 / (width - 1))
+	dy := int32((y - top) * 65535 / (height - 1))
+
+	flags := uint32(MOUSEEVENTF_MOVE | MOUSEEVENTF_ABSOLUTE | MOUSEEVENTF_VIRTUALDESK)
+	return send([]MOUSE_INPUT{mouseInput(flags, 0, dx, dy)})
+}
+
+func (w *Win32Injector) TypeRune(r rune) error {
+	// Переводы строк и табуляцию приложения ждут как обычные клавиши
+	switch r {
+	case '\n', '\r':
+		return w.Tap([]int{VK_RETURN})
+	case '\t':
+		return w.Tap([]int{VK_TAB})
+	}
+
+	units := utf16.Encode([]rune{r})
+	inputs := make([]INPUT, 0, len(units)*2)
+	for _, flags := range []uint32{KEYEVENTF_UNICODE, KEYEVENTF_UNICODE | KEYEVENTF_KEYUP} {
+		for _, unit := range units {
+			var in INPUT
+			in.Type = INPUT_KEYBOARD
+			in.Ki.Scan = unit
+			in.Ki.Flags = flags
+			in.Ki.ExtraInfo = EMULATED_FLAG
+			inputs = append(inputs, in)
+		}
+	}
+
+	return send(inputs)
+}
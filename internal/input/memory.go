@@ -0,0 +1,110 @@
+package input
+
+import "sync"
+
+type Op int
+
+const (
+	OpKeyDown Op = iota
+	OpKeyUp
+	OpMouseDown
+	OpMouseUp
+	OpWheel
+	OpMove
+	OpMoveTo
+	OpText
+)
+
+type Record struct {
+	Op     Op
+	Key    int
+	Button MouseButton
+	X      int
+	Y      int
+	Delta  int
+	Rune   rune
+	Mode   Mode
+}
+
+// MemoryInjector ничего не отправляет в систему, а только запоминает вызовы.
+// Нужен для тестов движка макросов на любой платформе.
+type MemoryInjector struct {
+	log  *memoryLog
+	mode Mode
+}
+
+type memoryLog struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func NewMemoryInjector() *MemoryInjector {
+	return &MemoryInjector{log: &memoryLog{}}
+}
+
+// WithMode возвращает injector с общим журналом, каждая запись помнит режим
+func (m *MemoryInjector) WithMode(mode Mode) Injector {
+	return &MemoryInjector{log: m.log, mode: mode}
+}
+
+func (m *MemoryInjector) record(r Record) error {
+	m.log.mu.Lock()
+	defer m.log.mu.Unlock()
+	r.Mode = m.mode
+	m.log.records = append(m.log.records, r)
+	return nil
+}
+
+func (m *MemoryInjector) KeyDown(key int) error {
+	return m.record(Record{Op: OpKeyDown, Key: key})
+}
+
+func (m *MemoryInjector) KeyUp(key int) error {
+	return m.record(Record{Op: OpKeyUp, Key: key})
+}
+
+func (m *MemoryInjector) Tap(keys []int) error {
+	for _, key := range keys {
+		m.KeyDown(key)
+	}
+	for _, key := range keys {
+		m.KeyUp(key)
+	}
+	return nil
+}
+
+func (m *MemoryInjector) Mouse(button MouseButton, down bool) error {
+	op := OpMouseUp
+	if down {
+		op = OpMouseDown
+	}
+	return m.record(Record{Op: op, Button: button})
+}
+
+func (m *MemoryInjector) Wheel(delta int) error {
+	return m.record(Record{Op: OpWheel, Delta: delta})
+}
+
+func (m *MemoryInjector) Move(dx, dy int) error {
+	return m.record(Record{Op: OpMove, X: dx, Y: dy})
+}
+
+func (m *MemoryInjector) MoveTo(x, y int) error {
+	return m.record(Record{Op: OpMoveTo, X: x, Y: y})
+}
+
+func (m *MemoryInjector) TypeRune(r rune) error {
+	return m.record(Record{Op: OpText, Rune: r})
+}
+
+func (m *MemoryInjector) Records() []Record {
+	m.log.mu.Lock()
+	defer m.log.mu.Unlock()
+	return append([]Record(nil), m.log.records...)
+}
+
+func (m *MemoryInjector) Reset() {
+	m.log.mu.Lock()
+	defer m.log.mu.Unlock()
+	m.log.records = nil
+}
@@ -0,0 +1,121 @@
+package input
+
+// Mode определяет, чем отправлять клавиши: virtual-key кодами или
+// аппаратными скан-кодами. Игры на DirectInput и raw input видят только второе.
+type Mode int
+
+const (
+	ModeVirtualKey Mode = iota
+	ModeScancode
+)
+
+// ModeInjector реализуют бэкенды, у которых режим вообще что-то меняет
+type ModeInjector interface {
+	WithMode(mode Mode) Injector
+}
+
+// WithMode возвращает injector в нужном режиме, а если бэкенд режимов
+// не различает (uinput, XTEST уже шлют физические клавиши), его же самого
+func WithMode(injector Injector, mode Mode) Injector {
+	if m, ok := injector.(ModeInjector); ok {
+		return m.WithMode(mode)
+	}
+	return injector
+}
+
+type scancode struct {
+	code     uint16
+	extended bool
+}
+
+// vkToScancode скан-коды набора 1. extended означает префикс E0,
+// для SendInput это флаг KEYEVENTF_EXTENDEDKEY
+var vkToScancode = map[int]scancode{
+	0x08: {0x0E, false}, // BACK
+	0x09: {0x0F, false}, // TAB
+	0x0D: {0x1C, false}, // RETURN
+	0x10: {0x2A, false}, // SHIFT
+	0x11: {0x1D, false}, // CONTROL
+	0x12: {0x38, false}, // MENU
+	0x14: {0x3A, false}, // CAPITAL
+	0x1B: {0x01, false}, // ESCAPE
+	0x20: {0x39, false}, // SPACE
+	0x21: {0x49, true},  // PRIOR
+	0x22: {0x51, true},  // NEXT
+	0x23: {0x4F, true},  // END
+	0x24: {0x47, true},  // HOME
+	0x25: {0x4B, true},  // LEFT
+	0x26: {0x48, true},  // UP
+	0x27: {0x4D, true},  // RIGHT
+	0x28: {0x50, true},  // DOWN
+	0x2C: {0x37, true},  // SNAPSHOT
+	0x2D: {0x52, true},  // INSERT
+	0x2E: {0x53, true},  // DELETE
+
+	0x30: {0x0B, false}, 0x31: {0x02, false}, 0x32: {0x03, false}, 0x33: {0x04, false}, 0x34: {0x05, false},
+	0x35: {0x06, false}, 0x36: {0x07, false}, 0x37: {0x08, false}, 0x38: {0x09, false}, 0x39: {0x0A, false},
+
+	0x41: {0x1E, false}, 0x42: {0x30, false}, 0x43: {0x2E, false}, 0x44: {0x20, false}, 0x45: {0x12, false},
+	0x46: {0x21, false}, 0x47: {0x22, false}, 0x48: {0x23, false}, 0x49: {0x17, false}, 0x4A: {0x24, false},
+	0x4B: {0x25, false}, 0x4C: {0x26, false}, 0x4D: {0x32, false}, 0x4E: {0x31, false}, 0x4F: {0x18, false},
+	0x50: {0x19, false}, 0x51: {0x10, false}, 0x52: {0x13, false}, 0x53: {0x1F, false}, 0x54: {0x14, false},
+	0x55: {0x16, false}, 0x56: {0x2F, false}, 0x57: {0x11, false}, 0x58: {0x2D, false}, 0x59: {0x15, false},
+	0x5A: {0x2C, false},
+
+	0x5B: {0x5B, true}, // LWIN
+	0x5C: {0x5C, true}, // RWIN
+	0x5D: {0x5D, true}, // APPS
+
+	0x60: {0x52, false}, 0x61: {0x4F, false}, 0x62: {0x50, false}, 0x63: {0x51, false}, 0x64: {0x4B, false},
+	0x65: {0x4C, false}, 0x66: {0x4D, false}, 0x67: {0x47, false}, 0x68: {0x48, false}, 0x69: {0x49, false},
+	0x6A: {0x37, false}, // MULTIPLY
+	0x6B: {0x4E, false}, // ADD
+	0x6D: {0x4A, false}, // SUBTRACT
+	0x6E: {0x53, false}, // DECIMAL
+	0x6F: {0x35, true},  // DIVIDE
+
+	0x70: {0x3B, false}, 0x71: {0x3C, false}, 0x72: {0x3D, false}, 0x73: {0x3E, false},
+	0x74: {0x3F, false}, 0x75: {0x40, false}, 0x76: {0x41, false}, 0x77: {0x42, false},
+	0x78: {0x43, false}, 0x79: {0x44, false}, 0x7A: {0x57, false}, 0x7B: {0x58, false},
+	0x7C: {0x64, false}, 0x7D: {0x65, false}, 0x7E: {0x66, false}, 0x7F: {0x67, false},
+	0x80: {0x68, false}, 0x81: {0x69, false}, 0x82: {0x6A, false}, 0x83: {0x6B, false},
+	0x84: {0x6C, false}, 0x85: {0x6D, false}, 0x86: {0x6E, false}, 0x87: {0x76, false},
+
+	0x90: {0x45, true},  // NUMLOCK, без E0 это Pause
+	0x91: {0x46, false}, // SCROLL
+
+	0xA0: {0x2A, false}, // LSHIFT
+	0xA1: {0x36, false}, // RSHIFT
+	0xA2: {0x1D, false}, // LCONTROL
+	0xA3: {0x1D, true},  // RCONTROL
+	0xA4: {0x38, false}, // LMENU
+	0xA5: {0x38, true},  // RMENU
+
+	0xAD: {0x20, true}, // VOLUME_MUTE
+	0xAE: {0x2E, true}, // VOLUME_DOWN
+	0xAF: {0x30, true}, // VOLUME_UP
+	0xB0: {0x19, true}, // MEDIA_NEXT_TRACK
+	0xB1: {0x10, true}, // MEDIA_PREV_TRACK
+	0xB2: {0x24, true}, // MEDIA_STOP
+	0xB3: {0x22, true}, // MEDIA_PLAY_PAUSE
+
+	0xBA: {0x27, false}, // OEM_1
+	0xBB: {0x0D, false}, // OEM_PLUS
+	0xBC: {0x33, false}, // OEM_COMMA
+	0xBD: {0x0C, false}, // OEM_MINUS
+	0xBE: {0x34, false}, // OEM_PERIOD
+	0xBF: {0x35, false}, // OEM_2
+	0xC0: {0x29, false}, // OEM_3
+	0xDB: {0x1A, false}, // OEM_4
+	0xDC: {0x2B, false}, // OEM_5
+	0xDD: {0x1B, false}, // OEM_6
+	0xDE: {0x28, false}, // OEM_7
+	0xE2: {0x56, false}, // OEM_102
+}
+
+// Scancode возвращает скан-код набора 1 для virtual-key кода.
+// Для клавиш без скан-кода (Pause, мышь) ok будет false.
+func Scancode(vk int) (code uint16, extended bool, ok bool) {
+	sc, ok := vkToScancode[vk]
+	return sc.code, sc.extended, ok
+}
@@ -0,0 +1,69 @@
+package input
+
+import "testing"
+
+func TestScancode(t *testing.T) {
+	tests := []struct {
+		name     string
+		vk       int
+		code     uint16
+		extended bool
+		ok       bool
+	}{
+		{"A", 0x41, 0x1E, false, true},
+		{"1", 0x31, 0x02, false, true},
+		{"F1", 0x70, 0x3B, false, true},
+		{"F24", 0x87, 0x76, false, true},
+		{"escape", 0x1B, 0x01, false, true},
+		{"left shift", 0xA0, 0x2A, false, true},
+		{"right shift", 0xA1, 0x36, false, true},
+		{"left control", 0xA2, 0x1D, false, true},
+		{"left alt", 0xA4, 0x38, false, true},
+		{"numpad 7", 0x67, 0x47, false, true},
+		{"scroll lock", 0x91, 0x46, false, true},
+
+		{"right control", 0xA3, 0x1D, true, true},
+		{"right alt", 0xA5, 0x38, true, true},
+		{"numlock", 0x90, 0x45, true, true},
+		{"numpad divide", 0x6F, 0x35, true, true},
+		{"home", 0x24, 0x47, true, true},
+		{"left", 0x25, 0x4B, true, true},
+		{"insert", 0x2D, 0x52, true, true},
+		{"delete", 0x2E, 0x53, true, true},
+		{"print screen", 0x2C, 0x37, true, true},
+		{"left win", 0x5B, 0x5B, true, true},
+		{"apps", 0x5D, 0x5D, true, true},
+		{"volume up", 0xAF, 0x30, true, true},
+		{"play pause", 0xB3, 0x22, true, true},
+
+		{"pause", 0x13, 0, false, false},
+		{"left mouse", 0x01, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, extended, ok := Scancode(tt.vk)
+			if code != tt.code || extended != tt.extended || ok != tt.ok {
+				t.Errorf("Scancode(%#x) = %#x, %v, %v, want %#x, %v, %v", tt.vk, code, extended, ok, tt.code, tt.extended, tt.ok)
+			}
+		})
+	}
+}
+
+// Одинаковые скан-коды допустимы только если их различает флаг extended:
+// Home и Numpad 7, Control и Right Control
+func TestScancodeUnique(t *testing.T) {
+	// SHIFT, CONTROL и MENU отправляются как левые
+	aliases := map[int]bool{0x10: true, 0x11: true, 0x12: true}
+
+	seen := make(map[scancode]int)
+	for vk, sc := range vkToScancode {
+		if aliases[vk] {
+			continue
+		}
+		if other, ok := seen[sc]; ok {
+			t.Errorf("vk %#x and %#x share scancode %#x extended=%v", vk, other, sc.code, sc.extended)
+		}
+		seen[sc] = vk
+	}
+}
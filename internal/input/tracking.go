@@ -0,0 +1,103 @@
+package input
+
+import "sync"
+
+// TrackingInjector пропускает ввод дальше и помнит, какие клавиши и кнопки
+// остались нажатыми, чтобы при аварийной остановке их можно было отпустить
+type TrackingInjector struct {
+	inner Injector
+	state *trackingState
+}
+
+type trackingState struct {
+	mu      sync.Mutex
+	keys    map[int]Injector
+	buttons map[MouseButton]Injector
+}
+
+func NewTrackingInjector(inner Injector) *TrackingInjector {
+	return &TrackingInjector{
+		inner: inner,
+		state: &trackingState{
+			keys:    make(map[int]Injector),
+			buttons: make(map[MouseButton]Injector),
+		},
+	}
+}
+
+// WithMode возвращает injector с общим списком нажатого, отпускание
+// идет через тот же режим, которым клавишу нажали
+func (t *TrackingInjector) WithMode(mode Mode) Injector {
+	return &TrackingInjector{inner: WithMode(t.inner, mode), state: t.state}
+}
+
+func (t *TrackingInjector) KeyDown(key int) error {
+	if err := t.inner.KeyDown(key); err != nil {
+		return err
+	}
+	t.state.mu.Lock()
+	t.state.keys[key] = t.inner
+	t.state.mu.Unlock()
+	return nil
+}
+
+func (t *TrackingInjector) KeyUp(key int) error {
+	t.state.mu.Lock()
+	delete(t.state.keys, key)
+	t.state.mu.Unlock()
+	return t.inner.KeyUp(key)
+}
+
+func (t *TrackingInjector) Tap(keys []int) error {
+	return t.inner.Tap(keys)
+}
+
+func (t *TrackingInjector) Mouse(button MouseButton, down bool) error {
+	if !down {
+		t.state.mu.Lock()
+		delete(t.state.buttons, button)
+		t.state.mu.Unlock()
+		return t.inner.Mouse(button, false)
+	}
+
+	if err := t.inner.Mouse(button, true); err != nil {
+		return err
+	}
+	t.state.mu.Lock()
+	t.state.buttons[button] = t.inner
+	t.state.mu.Unlock()
+	return nil
+}
+
+func (t *TrackingInjector) Wheel(delta int) error {
+	return t.inner.Wheel(delta)
+}
+
+func (t *TrackingInjector) Move(dx, dy int) error {
+	return t.inner.Move(dx, dy)
+}
+
+func (t *TrackingInjector) MoveTo(x, y int) error {
+	return t.inner.MoveTo(x, y)
+}
+
+func (t *TrackingInjector) TypeRune(r rune) error {
+	return t.inner.TypeRune(r)
+}
+
+// ReleaseAll отпускает все, что было нажато через этот injector и не отпущено
+func (t *TrackingInjector) ReleaseAll() {
+	t.state.mu.Lock()
+	keys := t.state.keys
+	buttons := t.state.buttons
+	t.state.keys = make(map[int]Injector)
+	t.state.buttons = make(map[MouseButton]Injector)
+	t.state.mu.Unlock()
+
+	for key, injector := range keys {
+		injector.KeyUp(key)
+	}
+	for button, injector := range buttons {
+		injector.Mouse(button, false)
+	}
+}
@@ -0,0 +1,452 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// UinputDeviceName имя виртуального устройства, по нему слушатель evdev
+// отличает наши события от настоящих
+const UinputDeviceName = "repeat-what-shit virtual input"
+
+const uinputPath = "/dev/uinput"
+
+const (
+	UI_DEV_CREATE  = 0x5501
+	UI_DEV_DESTROY = 0x5502
+	UI_DEV_SETUP   = 0x405C5503
+	UI_SET_EVBIT   = 0x40045564
+	UI_SET_KEYBIT  = 0x40045565
+	UI_SET_RELBIT  = 0x40045566
+	UI_SET_ABSBIT  = 0x40045567
+	UI_ABS_SETUP   = 0x401C5504
+
+	EV_SYN = 0x00
+	EV_KEY = 0x01
+	EV_REL = 0x02
+	EV_ABS = 0x03
+
+	SYN_REPORT = 0x00
+
+	REL_X     = 0x00
+	REL_Y     = 0x01
+	REL_WHEEL = 0x08
+
+	ABS_X = 0x00
+	ABS_Y = 0x01
+
+	BUS_VIRTUAL = 0x06
+
+	// KEY_MAX из linux/input-event-codes.h, регистрируем все коды до него
+	keyMax = 0x2FF
+)
+
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+type uinputAbsSetup struct {
+	Code       uint16
+	_          uint16
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+type uinputSetup struct {
+	BusType      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	Name         [80]byte
+	FFEffectsMax uint32
+}
+
+// UinputInjector виртуальная клавиатура и мышь через /dev/uinput.
+// Для абсолютных перемещений при первом MoveTo создается второе устройство,
+// libinput не принимает относительные и абсолютные оси у одной мыши.
+type UinputInjector struct {
+	mu   sync.Mutex
+	file *os.File
+
+	absOnce sync.Once
+	abs     *os.File
+	absErr  error
+
+	textOnce sync.Once
+	text     *X11Injector
+}
+
+func NewUinputInjector() (*UinputInjector, error) {
+	file, err := createUinputDevice(UinputDeviceName, setupPointerAndKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &UinputInjector{file: file}, nil
+}
+
+func ioctl(file *os.File, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func createUinputDevice(name string, configure func(file *os.File) error) (*os.File, error) {
+	file, err := os.OpenFile(uinputPath, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", uinputPath, err)
+	}
+
+	if err := configure(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	setup := uinputSetup{
+		BusType: BUS_VIRTUAL,
+		Vendor:  0x1234,
+		Product: 0x5678,
+		Version: 1,
+	}
+	copy(setup.Name[:], name)
+
+	if err := ioctl(file, UI_DEV_SETUP, uintptr(unsafe.Pointer(&setup))); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to setup uinput device: %w", err)
+	}
+
+	if err := ioctl(file, UI_DEV_CREATE, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create uinput device: %w", err)
+	}
+
+	// Даем компоситору и libinput время заметить новое устройство,
+	// иначе первые события теряются
+	time.Sleep(200 * time.Millisecond)
+
+	return file, nil
+}
+
+func setupPointerAndKeys(file *os.File) error {
+	for _, ev := range []uintptr{EV_KEY, EV_REL} {
+		if err := ioctl(file, UI_SET_EVBIT, ev); err != nil {
+			return fmt.Errorf("failed to enable event type %d: %w", ev, err)
+		}
+	}
+
+	for code := uintptr(1); code <= keyMax; code++ {
+		if err := ioctl(file, UI_SET_KEYBIT, code); err != nil {
+			return fmt.Errorf("failed to enable key %d: %w", code, err)
+		}
+	}
+
+	for _, rel := range []uintptr{REL_X, REL_Y, REL_WHEEL} {
+		if err := ioctl(file, UI_SET_RELBIT, rel); err != nil {
+			return fmt.Errorf("failed to enable axis %d: %w", rel, err)
+		}
+	}
+
+	return nil
+}
+
+func setupAbsolutePointer(width, height int) func(file *os.File) error {
+	return func(file *os.File) error {
+		for _, ev := range []uintptr{EV_KEY, EV_ABS} {
+			if err := ioctl(file, UI_SET_EVBIT, ev); err != nil {
+				return fmt.Errorf("failed to enable event type %d: %w", ev, err)
+			}
+		}
+
+		// Без кнопок libinput не считает устройство указателем
+		if err := ioctl(file, UI_SET_KEYBIT, evdevBtnLeft); err != nil {
+			return fmt.Errorf("failed to enable key %d: %w", evdevBtnLeft, err)
+		}
+
+		axes := []uinputAbsSetup{
+			{Code: ABS_X, Maximum: int32(width - 1)},
+			{Code: ABS_Y, Maximum: int32(height - 1)},
+		}
+		for i := range axes {
+			if err := ioctl(file, UI_SET_ABSBIT, uintptr(axes[i].Code)); err != nil {
+				return fmt.Errorf("failed to enable axis %d: %w", axes[i].Code, err)
+			}
+			if err := ioctl(file, UI_ABS_SETUP, uintptr(unsafe.Pointer(&axes[i]))); err != nil {
+				return fmt.Errorf("failed to setup axis %d: %w", axes[i].Code, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func (u *UinputInjector) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.abs != nil {
+		ioctl(u.abs, UI_DEV_DESTROY, 0)
+		u.abs.Close()
+	}
+	if u.text != nil {
+		u.text.Close()
+	}
+
+	ioctl(u.file, UI_DEV_DESTROY, 0)
+	return u.file.Close()
+}
+
+// emit пишет события одной пачкой и завершает ее SYN_REPORT
+func (u *UinputInjector) emit(events ...inputEvent) error {
+	return u.write(u.file, events...)
+}
+
+func (u *UinputInjector) write(file *os.File, events ...inputEvent) error {
+	events = append(events, inputEvent{Type: EV_SYN, Code: SYN_REPORT})
+
+	size := int(unsafe.Sizeof(inputEvent{}))
+	buf := make([]byte, 0, size*len(events))
+	for i := range events {
+		buf = append(buf, unsafe.Slice((*byte)(unsafe.Pointer(&events[i])), size)...)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, err := file.Write(buf); err != nil {
+		return fmt.Errorf("failed to write uinput event: %w", err)
+	}
+	return nil
+}
+
+func keyEvents(keys []int, value int32) []inputEvent {
+	events := make([]inputEvent, 0, len(keys))
+	for _, key := range keys {
+		if code, ok := VKToEvdev(key); ok {
+			events = append(events, inputEvent{Type: EV_KEY, Code: code, Value: value})
+		}
+	}
+	return events
+}
+
+func (u *UinputInjector) KeyDown(key int) error {
+	if ticks, ok := wheelTicks(key); ok {
+		return u.Wheel(ticks * WheelDeltaStep)
+	}
+	return u.emit(keyEvents([]int{key}, 1)...)
+}
+
+func (u *UinputInjector) KeyUp(key int) error {
+	if _, ok := wheelTicks(key); ok {
+		return nil
+	}
+	return u.emit(keyEvents([]int{key}, 0)...)
+}
+
+func (u *UinputInjector) Tap(keys []int) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var pressed []int
+	for _, key := range keys {
+		if ticks, ok := wheelTicks(key); ok {
+			if err := u.Wheel(ticks * WheelDeltaStep); err != nil {
+				return err
+			}
+			continue
+		}
+		pressed = append(pressed, key)
+	}
+
+	if err := u.emit(keyEvents(pressed, 1)...); err != nil {
+		return err
+	}
+
+	time.Sleep(tapDelay)
+
+	return u.emit(keyEvents(pressed, 0)...)
+}
+
+func (u *UinputInjector) Mouse(button MouseButton, down bool) error {
+	var code uint16
+	switch button {
+	case MouseLeft:
+		code = evdevBtnLeft
+	case MouseRight:
+		code = evdevBtnRight
+	case MouseMiddle:
+		code = evdevBtnMiddle
+	case MouseX1:
+		code = evdevBtnSide
+	case MouseX2:
+		code = evdevBtnExtra
+	default:
+		return nil
+	}
+
+	var value int32
+	if down {
+		value = 1
+	}
+	return u.emit(inputEvent{Type: EV_KEY, Code: code, Value: value})
+}
+
+func (u *UinputInjector) Wheel(delta int) error {
+	ticks := delta / WheelDeltaStep
+	if ticks == 0 && delta != 0 {
+		ticks = 1
+		if delta < 0 {
+			ticks = -1
+		}
+	}
+	return u.emit(inputEvent{Type: EV_REL, Code: REL_WHEEL, Value: int32(ticks)})
+}
+
+func (u *UinputInjector) Move(dx, dy int) error {
+	return u.emit(
+		inputEvent{Type: EV_REL, Code: REL_X, Value: int32(dx)},
+		inputEvent{Type: EV_REL, Code: REL_Y, Value: int32(dy)},
+	)
+}
+
+func (u *UinputInjector) MoveTo(x, y int) error {
+	u.absOnce.Do(func() {
+		width, height, err := screenSize()
+		if err != nil {
+			u.absErr = fmt.Errorf("absolute move needs screen size: %w", err)
+			return
+		}
+		u.abs, u.absErr = createUinputDevice(UinputDeviceName+" (absolute)", setupAbsolutePointer(width, height))
+	})
+
+	if u.absErr != nil {
+		return u.absErr
+	}
+
+	return u.write(u.abs,
+		inputEvent{Type: EV_ABS, Code: ABS_X, Value: int32(x)},
+		inputEvent{Type: EV_ABS, Code: ABS_Y, Value: int32(y)},
+	)
+}
+
+const (
+	evdevLeftCtrl  = 29
+	evdevLeftShift = 42
+	evdevSpace     = 57
+	evdevU         = 22
+)
+
+type evdevChar struct {
+	code  uint16
+	shift bool
+}
+
+// asciiChars раскладка US, uinput работает на уровне физических клавиш
+// и не знает, какая раскладка сейчас активна
+var asciiChars = map[rune]evdevChar{
+	' ': {57, false}, '\n': {28, false}, '\r': {28, false}, '\t': {15, false},
+	'-': {12, false}, '_': {12, true}, '=': {13, false}, '+': {13, true},
+	'[': {26, false}, '{': {26, true}, ']': {27, false}, '}': {27, true},
+	'\\': {43, false}, '|': {43, true}, ';': {39, false}, ':': {39, true},
+	'\'': {40, false}, '"': {40, true}, '`': {41, false}, '~': {41, true},
+	',': {51, false}, '<': {51, true}, '.': {52, false}, '>': {52, true},
+	'/': {53, false}, '?': {53, true},
+	'!': {2, true}, '@': {3, true}, '#': {4, true}, '$': {5, true}, '%': {6, true},
+	'^': {7, true}, '&': {8, true}, '*': {9, true}, '(': {10, true}, ')': {11, true},
+}
+
+func charToEvdev(r rune) (evdevChar, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		code, _ := VKToEvdev(int(r - 'a' + 'A'))
+		return evdevChar{code, false}, true
+	case r >= 'A' && r <= 'Z':
+		code, _ := VKToEvdev(int(r))
+		return evdevChar{code, true}, true
+	case r >= '0' && r <= '9':
+		code, _ := VKToEvdev(int(r))
+		return evdevChar{code, false}, true
+	}
+
+	char, ok := asciiChars[r]
+	return char, ok
+}
+
+func (u *UinputInjector) typeChar(char evdevChar) error {
+	if char.shift {
+		return u.tapCode(char.code, evdevLeftShift)
+	}
+	return u.tapCode(char.code)
+}
+
+func (u *UinputInjector) tapCode(code uint16, modifiers ...uint16) error {
+	var down, up []inputEvent
+	for _, modifier := range modifiers {
+		down = append(down, inputEvent{Type: EV_KEY, Code: modifier, Value: 1})
+	}
+	down = append(down, inputEvent{Type: EV_KEY, Code: code, Value: 1})
+	up = append(up, inputEvent{Type: EV_KEY, Code: code, Value: 0})
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		up = append(up, inputEvent{Type: EV_KEY, Code: modifiers[i], Value: 0})
+	}
+
+	if err := u.emit(down...); err != nil {
+		return err
+	}
+	return u.emit(up...)
+}
+
+// textInjector XTEST печатает по keysym с учетом текущей раскладки X.
+// Под Wayland XTEST доходит только до X клиентов, поэтому там его не берем.
+func (u *UinputInjector) textInjector() *X11Injector {
+	u.textOnce.Do(func() {
+		if os.Getenv("DISPLAY") == "" || os.Getenv("WAYLAND_DISPLAY") != "" {
+			return
+		}
+		if text, err := NewX11Injector(""); err == nil {
+			u.text = text
+		}
+	})
+	return u.text
+}
+
+// TypeRune печатает через XTEST, если есть X сервер, тогда символы берутся
+// из активной раскладки. Без X (или под Wayland) ASCII идет физическими
+// клавишами раскладки US, как и раньше, а остальное через Ctrl+Shift+U и
+// шестнадцатеричный код. У этого пути есть пределы: код понимают только
+// GTK приложения и IBus, а цифры кода тоже идут физическими клавишами,
+// так что при не латинской раскладке и ASCII, и Unicode напечатаются неверно.
+func (u *UinputInjector) TypeRune(r rune) error {
+	if text := u.textInjector(); text != nil {
+		return text.TypeRune(r)
+	}
+
+	if char, ok := charToEvdev(r); ok {
+		return u.typeChar(char)
+	}
+
+	if err := u.tapCode(evdevU, evdevLeftCtrl, evdevLeftShift); err != nil {
+		return err
+	}
+
+	for _, digit := range strconv.FormatInt(int64(r), 16) {
+		char, _ := charToEvdev(digit)
+		if err := u.typeChar(char); err != nil {
+			return err
+		}
+	}
+
+	return u.tapCode(evdevSpace)
+}
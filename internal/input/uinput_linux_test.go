@@ -0,0 +1,36 @@
+package input
+
+import "testing"
+
+func TestCharToEvdevCoversPrintableASCII(t *testing.T) {
+	for r := rune(0x20); r <= 0x7E; r++ {
+		char, ok := charToEvdev(r)
+		if !ok || char.code == 0 {
+			t.Errorf("%q has no evdev key", r)
+		}
+	}
+
+	for _, r := range "\n\r\t" {
+		if _, ok := charToEvdev(r); !ok {
+			t.Errorf("%q has no evdev key", r)
+		}
+	}
+}
+
+func TestCharToEvdevHexDigitsUnshifted(t *testing.T) {
+	// Ctrl+Shift+U ждет код без Shift, иначе IBus примет его за другой символ
+	for _, r := range "0123456789abcdef" {
+		char, _ := charToEvdev(r)
+		if char.shift {
+			t.Errorf("hex digit %q is typed with Shift", r)
+		}
+	}
+}
+
+func TestCharToEvdevNonASCII(t *testing.T) {
+	for _, r := range "йé☃" {
+		if _, ok := charToEvdev(r); ok {
+			t.Errorf("%q is typed by a physical key", r)
+		}
+	}
+}
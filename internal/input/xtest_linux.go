@@ -0,0 +1,418 @@
+package input
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+	"github.com/jezek/xgb/xtest"
+)
+
+// На серверах с evdev/libinput раскладкой X keycode = evdev код + 8
+const X11KeycodeOffset = 8
+
+const x11EchoTTL = time.Second
+
+var x11Buttons = map[MouseButton]byte{
+	MouseLeft:   1,
+	MouseMiddle: 2,
+	MouseRight:  3,
+	MouseX1:     8,
+	MouseX2:     9,
+}
+
+// Колесо в X это кнопки 4 и 5
+const (
+	X11WheelUp   = 4
+	X11WheelDown = 5
+)
+
+// X11ButtonCode переводит номер кнопки X в код кнопки мыши, как его сохраняет фронт
+func X11ButtonCode(detail byte) (int, bool) {
+	for button, x11Detail := range x11Buttons {
+		if x11Detail == detail {
+			return buttonCodes[button], true
+		}
+	}
+	return 0, false
+}
+
+// x11EchoLog помнит события, отправленные через XTEST. XRecord видит их наравне
+// с настоящими, поэтому слушатель сверяется с этим списком вместо EMULATED_FLAG.
+type x11EchoLog struct {
+	mu      sync.Mutex
+	pending map[[2]byte][]time.Time
+}
+
+var x11Echo = &x11EchoLog{pending: make(map[[2]byte][]time.Time)}
+
+func (l *x11EchoLog) expect(eventType, detail byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := [2]byte{eventType, detail}
+	l.pending[key] = append(dropExpired(l.pending[key]), time.Now())
+}
+
+// dropExpired убирает записи, эхо которых так и не пришло,
+// например когда слушает evdev, а XTEST только печатает текст
+func dropExpired(queue []time.Time) []time.Time {
+	for len(queue) > 0 && time.Since(queue[0]) > x11EchoTTL {
+		queue = queue[1:]
+	}
+	return queue
+}
+
+func (l *x11EchoLog) consume(eventType, detail byte) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := [2]byte{eventType, detail}
+	queue := dropExpired(l.pending[key])
+
+	if len(queue) == 0 {
+		delete(l.pending, key)
+		return false
+	}
+
+	l.pending[key] = queue[1:]
+	return true
+}
+
+// IsX11Echo сообщает, что событие ядра X с таким типом и detail отправили мы сами
+func IsX11Echo(eventType, detail byte) bool {
+	return x11Echo.consume(eventType, detail)
+}
+
+// X11Injector отправляет ввод через расширение XTEST,
+// не требует доступа к /dev/uinput
+type X11Injector struct {
+	conn *xgb.Conn
+	root xproto.Window
+
+	// keymapMu защищает раскладку, TypeRune могут звать из разных макросов
+	keymapMu   sync.Mutex
+	keymap     map[xproto.Keysym]x11Key
+	group      int
+	perKeycode byte
+	spare      xproto.Keycode
+}
+
+type x11Key struct {
+	code  xproto.Keycode
+	shift bool
+}
+
+// NewX11Injector подключается к дисплею display, пустая строка значит $DISPLAY
+func NewX11Injector(display string) (*X11Injector, error) {
+	conn, err := xgb.NewConnDisplay(display)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	if err := xtest.Init(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("XTEST extension is not available: %w", err)
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	return &X11Injector{conn: conn, root: root}, nil
+}
+
+// screenSize размер корневого окна X, то есть всего виртуального рабочего стола
+func screenSize() (int, int, error) {
+	conn, err := xgb.NewConnDisplay("")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	return int(screen.WidthInPixels), int(screen.HeightInPixels), nil
+}
+
+func (x *X11Injector) Close() error {
+	x.conn.Close()
+	return nil
+}
+
+func (x *X11Injector) fake(eventType, detail byte, rootX, rootY int16) error {
+	if eventType != xproto.MotionNotify {
+		x11Echo.expect(eventType, detail)
+	}
+
+	err := xtest.FakeInputChecked(x.conn, eventType, detail, 0, x.root, rootX, rootY, 0).Check()
+	if err != nil {
+		return fmt.Errorf("failed to send fake input: %w", err)
+	}
+	return nil
+}
+
+func (x *X11Injector) key(key int, down bool) error {
+	if button, ok := ButtonFromCode(key); ok {
+		return x.Mouse(button, down)
+	}
+
+	code, ok := VKToEvdev(key)
+	if !ok {
+		return nil
+	}
+
+	eventType := byte(xproto.KeyRelease)
+	if down {
+		eventType = xproto.KeyPress
+	}
+	return x.fake(eventType, byte(code+X11KeycodeOffset), 0, 0)
+}
+
+func (x *X11Injector) KeyDown(key int) error {
+	if ticks, ok := wheelTicks(key); ok {
+		return x.Wheel(ticks * WheelDeltaStep)
+	}
+	return x.key(key, true)
+}
+
+func (x *X11Injector) KeyUp(key int) error {
+	if _, ok := wheelTicks(key); ok {
+		return nil
+	}
+	return x.key(key, false)
+}
+
+func (x *X11Injector) Tap(keys []int) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := x.KeyDown(key); err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(tapDelay)
+
+	for _, key := range keys {
+		if err := x.KeyUp(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *X11Injector) Mouse(button MouseButton, down bool) error {
+	detail, ok := x11Buttons[button]
+	if !ok {
+		return nil
+	}
+
+	eventType := byte(xproto.ButtonRelease)
+	if down {
+		eventType = xproto.ButtonPress
+	}
+	return x.fake(eventType, detail, 0, 0)
+}
+
+func (x *X11Injector) Wheel(delta int) error {
+	detail := byte(X11WheelUp)
+	if delta < 0 {
+		detail = X11WheelDown
+		delta = -delta
+	}
+
+	ticks := delta / WheelDeltaStep
+	if ticks == 0 && delta != 0 {
+		ticks = 1
+	}
+
+	for i := 0; i < ticks; i++ {
+		if err := x.fake(xproto.ButtonPress, detail, 0, 0); err != nil {
+			return err
+		}
+		if err := x.fake(xproto.ButtonRelease, detail, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *X11Injector) Move(dx, dy int) error {
+	// detail 1 означает относительное перемещение
+	return x.fake(xproto.MotionNotify, 1, int16(dx), int16(dy))
+}
+
+func (x *X11Injector) MoveTo(x0, y0 int) error {
+	// detail 0 означает координаты относительно корневого окна
+	return x.fake(xproto.MotionNotify, 0, int16(x0), int16(y0))
+}
+
+const (
+	x11ShiftKeycode = 42 + X11KeycodeOffset
+	x11KeysymReturn = 0xFF0D
+	x11KeysymTab    = 0xFF09
+)
+
+// runeKeysym переводит символ в keysym: Latin-1 совпадает с кодом символа,
+// остальной Unicode кодируется как 0x01000000 + код
+func runeKeysym(r rune) xproto.Keysym {
+	switch {
+	case r == '\n' || r == '\r':
+		return x11KeysymReturn
+	case r == '\t':
+		return x11KeysymTab
+	case r >= 0x20 && r <= 0x7E, r >= 0xA0 && r <= 0xFF:
+		return xproto.Keysym(r)
+	}
+	return xproto.Keysym(0x01000000 | r)
+}
+
+// activeGroup номер активной группы XKB от 0 до 3, сервер кладет его
+// в биты 13-14 состояния клавиатуры
+func (x *X11Injector) activeGroup() (int, error) {
+	reply, err := xproto.QueryPointer(x.conn, x.root).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query keyboard state: %w", err)
+	}
+	return int(reply.Mask>>13) & 3, nil
+}
+
+// refreshKeymap перечитывает раскладку, если ее поменяли (MappingNotify приходит
+// всем клиентам без подписки) или переключили группу
+func (x *X11Injector) refreshKeymap() error {
+	for {
+		event, err := x.conn.PollForEvent()
+		if event == nil && err == nil {
+			break
+		}
+		notify, ok := event.(xproto.MappingNotifyEvent)
+		if !ok || notify.Request != xproto.MappingKeyboard {
+			continue
+		}
+		// Свои переназначения запасного keycode раскладку не меняют
+		if notify.FirstKeycode == x.spare && notify.Count == 1 {
+			continue
+		}
+		x.keymap = nil
+	}
+
+	group, err := x.activeGroup()
+	if err != nil {
+		return err
+	}
+	if x.keymap != nil && group == x.group {
+		return nil
+	}
+	return x.loadKeymap(group)
+}
+
+// loadKeymap запоминает, какие keysym есть в группе group, и ищет свободный
+// keycode, на который можно временно назначить любой другой символ.
+// В core раскладке у групп 1 и 2 свои столбцы, а место групп 3 и 4 зависит
+// от числа уровней, поэтому в них все печатается через запасной keycode.
+func (x *X11Injector) loadKeymap(group int) error {
+	setup := xproto.Setup(x.conn)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+
+	reply, err := xproto.GetKeyboardMapping(x.conn, setup.MinKeycode, count).Reply()
+	if err != nil {
+		return fmt.Errorf("failed to get keyboard mapping: %w", err)
+	}
+
+	x.group = group
+	x.perKeycode = reply.KeysymsPerKeycode
+	x.keymap = make(map[xproto.Keysym]x11Key)
+	x.spare = 0
+
+	per := int(reply.KeysymsPerKeycode)
+	first := 2 * group
+	for i := 0; i < int(count); i++ {
+		code := setup.MinKeycode + xproto.Keycode(i)
+		syms := reply.Keysyms[i*per : (i+1)*per]
+
+		if slices.IndexFunc(syms, func(sym xproto.Keysym) bool { return sym != 0 }) < 0 {
+			x.spare = code
+			continue
+		}
+		if group > 1 || first+1 >= per {
+			continue
+		}
+
+		// Клавиша без символов во второй группе берет их из первой
+		col := first
+		if syms[col] == 0 && syms[col+1] == 0 {
+			col = 0
+		}
+		for level := 0; level < 2; level++ {
+			sym := syms[col+level]
+			if _, exists := x.keymap[sym]; sym != 0 && !exists {
+				x.keymap[sym] = x11Key{code: code, shift: level == 1}
+			}
+		}
+	}
+	return nil
+}
+
+// x11RemapDelay дает клиентам перечитать раскладку после MappingNotify.
+// Без паузы они переводят нажатие по старой или уже восстановленной раскладке.
+const x11RemapDelay = 30 * time.Millisecond
+
+func (x *X11Injector) TypeRune(r rune) error {
+	x.keymapMu.Lock()
+	defer x.keymapMu.Unlock()
+
+	if err := x.refreshKeymap(); err != nil {
+		return err
+	}
+
+	keysym := runeKeysym(r)
+	if key, ok := x.keymap[keysym]; ok {
+		return x.typeKey(key)
+	}
+
+	if x.spare == 0 {
+		return fmt.Errorf("no free keycode to type %q", r)
+	}
+	return x.typeRemapped(keysym)
+}
+
+// typeRemapped временно назначает keysym на запасной keycode во всех столбцах,
+// так что он печатается в любой группе, а после нажатия возвращает keycode пустым
+func (x *X11Injector) typeRemapped(keysym xproto.Keysym) error {
+	syms := make([]xproto.Keysym, x.perKeycode)
+	for i := range syms {
+		syms[i] = keysym
+	}
+	if err := xproto.ChangeKeyboardMappingChecked(x.conn, 1, x.spare, x.perKeycode, syms).Check(); err != nil {
+		return fmt.Errorf("failed to remap keycode: %w", err)
+	}
+
+	time.Sleep(x11RemapDelay)
+	typeErr := x.typeKey(x11Key{code: x.spare})
+	time.Sleep(x11RemapDelay)
+
+	empty := make([]xproto.Keysym, x.perKeycode)
+	if err := xproto.ChangeKeyboardMappingChecked(x.conn, 1, x.spare, x.perKeycode, empty).Check(); err != nil && typeErr == nil {
+		return fmt.Errorf("failed to restore keycode: %w", err)
+	}
+	return typeErr
+}
+
+func (x *X11Injector) typeKey(key x11Key) error {
+	if key.shift {
+		if err := x.fake(xproto.KeyPress, x11ShiftKeycode, 0, 0); err != nil {
+			return err
+		}
+	}
+	if err := x.fake(xproto.KeyPress, byte(key.code), 0, 0); err != nil {
+		return err
+	}
+	if err := x.fake(xproto.KeyRelease, byte(key.code), 0, 0); err != nil {
+		return err
+	}
+	if key.shift {
+		return x.fake(xproto.KeyRelease, x11ShiftKeycode, 0, 0)
+	}
+	return nil
+}
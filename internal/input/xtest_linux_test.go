@@ -0,0 +1,134 @@
+package input
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Тесты идут против настоящего X сервера, например xvfb-run go test ./...
+func newTestX11Injector(t *testing.T) *X11Injector {
+	t.Helper()
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set")
+	}
+
+	injector, err := NewX11Injector("")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { injector.Close() })
+	return injector
+}
+
+func isX11KeyDown(t *testing.T, conn *xgb.Conn, keycode byte) bool {
+	t.Helper()
+	reply, err := xproto.QueryKeymap(conn).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply.Keys[keycode/8]&(1<<(keycode%8)) != 0
+}
+
+func TestX11InjectorKeyDownUp(t *testing.T) {
+	injector := newTestX11Injector(t)
+
+	const vkA = 0x41
+	code, _ := VKToEvdev(vkA)
+	keycode := byte(code + X11KeycodeOffset)
+
+	if err := injector.KeyDown(vkA); err != nil {
+		t.Fatal(err)
+	}
+	if !isX11KeyDown(t, injector.conn, keycode) {
+		t.Fatalf("keycode %d is not pressed after KeyDown", keycode)
+	}
+
+	if err := injector.KeyUp(vkA); err != nil {
+		t.Fatal(err)
+	}
+	if isX11KeyDown(t, injector.conn, keycode) {
+		t.Fatalf("keycode %d is still pressed after KeyUp", keycode)
+	}
+}
+
+func TestX11InjectorMarksEcho(t *testing.T) {
+	injector := newTestX11Injector(t)
+
+	const vkB = 0x42
+	code, _ := VKToEvdev(vkB)
+	keycode := byte(code + X11KeycodeOffset)
+
+	if err := injector.Tap([]int{vkB}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsX11Echo(xproto.KeyPress, keycode) || !IsX11Echo(xproto.KeyRelease, keycode) {
+		t.Fatal("injected key is not recognized as echo")
+	}
+	if IsX11Echo(xproto.KeyPress, keycode) {
+		t.Fatal("echo is consumed more than once")
+	}
+}
+
+func TestX11TypeRuneRestoresSpareKeycode(t *testing.T) {
+	injector := newTestX11Injector(t)
+
+	// Символа нет ни в одной обычной раскладке, печатается через запасной keycode
+	if err := injector.TypeRune('☃'); err != nil {
+		t.Skip(err)
+	}
+
+	reply, err := xproto.GetKeyboardMapping(injector.conn, injector.spare, 1).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sym := range reply.Keysyms {
+		if sym != 0 {
+			t.Fatalf("spare keycode %d still maps to %#x", injector.spare, sym)
+		}
+	}
+}
+
+func TestX11KeymapReloadsOnMappingNotify(t *testing.T) {
+	injector := newTestX11Injector(t)
+	if err := injector.TypeRune('a'); err != nil {
+		t.Fatal(err)
+	}
+	if injector.keymap == nil {
+		t.Fatal("keymap is not loaded after TypeRune")
+	}
+
+	// Пустая карта останется пустой, если injector не заметит MappingNotify
+	key := injector.keymap['a']
+	injector.keymap = map[xproto.Keysym]x11Key{}
+
+	// Чужое изменение раскладки: переназначаем keycode буквы на ту же букву
+	reply, err := xproto.GetKeyboardMapping(injector.conn, key.code, 1).Reply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := xgb.NewConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := xproto.ChangeKeyboardMappingChecked(other, 1, key.code, reply.KeysymsPerKeycode, reply.Keysyms).Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// MappingNotify приходит асинхронно, ждем его через лишний запрос
+	xproto.GetInputFocus(injector.conn).Reply()
+	injector.keymapMu.Lock()
+	err = injector.refreshKeymap()
+	reloaded := injector.keymap
+	injector.keymapMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded['a']; !ok {
+		t.Fatal("keymap was not reloaded after MappingNotify")
+	}
+}
@@ -0,0 +1,199 @@
+package recorder
+
+import (
+	"repeat-what-shit/internal/hotkeys"
+	"repeat-what-shit/internal/types"
+	"slices"
+)
+
+// Нажатие короче этого превращается при слиянии в обычный tap/клик,
+// длиннее в удержание на записанное время. Обычный удар по клавише
+// держит ее 50-150 мс, так что порог с запасом выше.
+const tapHoldThreshold = 250
+
+type Options struct {
+	// DropKeys хоткей остановки записи, выкидывается его последнее нажатие
+	DropKeys types.Combo `json:"drop_keys"`
+	// MergeTaps склеивает соседние нажатие и отпускание одной клавиши
+	MergeTaps bool `json:"merge_taps"`
+	// TrimIdle убирает паузы в начале и в конце записи
+	TrimIdle bool `json:"trim_idle"`
+	// Quantize округляет задержки до кратных этому числу мс, 0 выключено
+	Quantize int `json:"quantize"`
+	MinDelay int `json:"min_delay"`
+	// MaxDelay 0 значит без ограничения
+	MaxDelay int `json:"max_delay"`
+}
+
+// Process прогоняет записанные действия через шаги обработки в порядке:
+// выброс клавиш остановки, слияние, обрезка пауз, квантование, ограничение задержек
+func Process(actions []types.MacroAction, opts Options) []types.MacroAction {
+	result := slices.Clone(actions)
+
+	if len(opts.DropKeys) > 0 {
+		result = dropKeys(result, opts.DropKeys)
+	}
+	if opts.MergeTaps {
+		result = mergeTaps(result)
+	}
+	if opts.TrimIdle {
+		result = trimIdle(result)
+	}
+	if opts.Quantize > 0 {
+		result = quantize(result, opts.Quantize)
+	}
+	if opts.MinDelay > 0 || opts.MaxDelay > 0 {
+		result = clampDelays(result, opts.MinDelay, opts.MaxDelay)
+	}
+
+	return result
+}
+
+// remove выкидывает действие, отдавая его задержку предыдущему,
+// чтобы остальные события не сдвинулись во времени
+func remove(actions []types.MacroAction, i int) []types.MacroAction {
+	if i > 0 {
+		actions[i-1].Delay += actions[i].Delay
+	}
+	return slices.Delete(actions, i, i+1)
+}
+
+// dropKeys убирает последнее нажатие хоткея, которым запись остановили: нажатия
+// его клавиш прямо перед ним и отпускания после. Такие же клавиши раньше в записи
+// остаются как есть. Модификатор без стороны в хоткее совпадает с любой стороной.
+func dropKeys(actions []types.MacroAction, combo types.Combo) []types.MacroAction {
+	var pressed types.Combo
+	last := -1
+	for i, action := range actions {
+		key, ok := actionKey(action)
+		if !ok {
+			continue
+		}
+		if action.Kind == types.ActionDown {
+			pressed = append(pressed, key)
+			if hotkeys.Match(pressed, combo) {
+				last = i
+			}
+		} else {
+			pressed = slices.DeleteFunc(pressed, func(k int) bool { return k == key })
+		}
+	}
+	if last < 0 {
+		return actions
+	}
+
+	inCombo := func(key int) bool {
+		return combo.Contains(hotkeys.Normalize(types.Combo{key}, combo))
+	}
+
+	dropped := make(map[int]bool)
+	for i := last; i >= 0; i-- {
+		key, ok := actionKey(actions[i])
+		if !ok || actions[i].Kind != types.ActionDown || !inCombo(key) || dropped[key] {
+			break
+		}
+		dropped[key] = true
+		actions = remove(actions, i)
+	}
+
+	for i := last + 1 - len(dropped); i < len(actions); {
+		key, ok := actionKey(actions[i])
+		if ok && actions[i].Kind == types.ActionUp && dropped[key] {
+			delete(dropped, key)
+			actions = remove(actions, i)
+			continue
+		}
+		i++
+	}
+	return actions
+}
+
+// actionKey клавиша записанного нажатия или отпускания, у Convert она всегда одна
+func actionKey(action types.MacroAction) (int, bool) {
+	if (action.Kind != types.ActionDown && action.Kind != types.ActionUp) || len(action.Keys) != 1 {
+		return 0, false
+	}
+	return action.Keys[0], true
+}
+
+func mergeTaps(actions []types.MacroAction) []types.MacroAction {
+	merged := make([]types.MacroAction, 0, len(actions))
+
+	for i := 0; i < len(actions); i++ {
+		action := actions[i]
+		if i+1 >= len(actions) {
+			merged = append(merged, action)
+			continue
+		}
+
+		next := actions[i+1]
+		hold := action.Delay
+
+		// tap и клик отпускают сразу, время нажатия уходит в задержку после них,
+		// чтобы следующие действия не сдвинулись
+		switch {
+		case action.Kind == types.ActionDown && next.Kind == types.ActionUp &&
+			types.Combo(action.Keys).Equal(next.Keys):
+			action.Kind = types.ActionTap
+			action.Delay = hold + next.Delay
+			if hold >= tapHoldThreshold {
+				action.Kind = types.ActionHold
+				action.Duration = hold
+				action.Delay = next.Delay
+			}
+
+		case action.Kind == types.ActionMouseDown && next.Kind == types.ActionMouseUp &&
+			action.Button == next.Button && hold < tapHoldThreshold:
+			action.Kind = types.ActionClick
+			action.Delay = hold + next.Delay
+
+		default:
+			merged = append(merged, action)
+			continue
+		}
+
+		merged = append(merged, action)
+		i++
+	}
+
+	return merged
+}
+
+// trimIdle убирает паузу до первого события, которую Convert записывает
+// отдельным ActionDelay, и паузу после последнего
+func trimIdle(actions []types.MacroAction) []types.MacroAction {
+	for len(actions) > 0 && actions[0].Kind == types.ActionDelay {
+		actions = actions[1:]
+	}
+	for len(actions) > 0 && actions[len(actions)-1].Kind == types.ActionDelay {
+		actions = actions[:len(actions)-1]
+	}
+	if len(actions) > 0 {
+		actions[len(actions)-1].Delay = 0
+	}
+	return actions
+}
+
+func quantize(actions []types.MacroAction, grid int) []types.MacroAction {
+	for i := range actions {
+		actions[i].Delay = (actions[i].Delay + grid/2) / grid * grid
+		if actions[i].Kind == types.ActionHold {
+			actions[i].Duration = max((actions[i].Duration+grid/2)/grid*grid, grid)
+		}
+	}
+	return actions
+}
+
+// clampDelays не трогает последнее действие, иначе после обрезки
+// в конце макроса снова появилась бы пауза
+func clampDelays(actions []types.MacroAction, minDelay, maxDelay int) []types.MacroAction {
+	for i := 0; i+1 < len(actions); i++ {
+		if actions[i].Delay < minDelay {
+			actions[i].Delay = minDelay
+		}
+		if maxDelay > 0 && actions[i].Delay > maxDelay {
+			actions[i].Delay = maxDelay
+		}
+	}
+	return actions
+}
@@ -0,0 +1,110 @@
+package recorder
+
+import (
+	"repeat-what-shit/internal/hotkeys"
+	"repeat-what-shit/internal/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	vkC     = 0x43
+	vkR     = 0x52
+	vkLCtrl = hotkeys.VK_LCONTROL
+	vkRCtrl = hotkeys.VK_RCONTROL
+)
+
+func down(key, delay int) types.MacroAction {
+	return types.MacroAction{Kind: types.ActionDown, Keys: []int{key}, Delay: delay}
+}
+
+func up(key, delay int) types.MacroAction {
+	return types.MacroAction{Kind: types.ActionUp, Keys: []int{key}, Delay: delay}
+}
+
+func equalActions(a, b []types.MacroAction) bool {
+	return slices.EqualFunc(a, b, func(x, y types.MacroAction) bool {
+		return x.Kind == y.Kind && slices.Equal(x.Keys, y.Keys) && x.Delay == y.Delay && x.Duration == y.Duration
+	})
+}
+
+func TestDropKeysStripsOnlyLastHotkey(t *testing.T) {
+	// Ctrl+C в записи остается, а Ctrl+R в конце был хоткеем остановки
+	actions := []types.MacroAction{
+		down(vkLCtrl, 10), down(vkC, 20), up(vkC, 30), up(vkLCtrl, 40),
+		down(vkRCtrl, 5), down(vkR, 0),
+	}
+	want := []types.MacroAction{
+		down(vkLCtrl, 10), down(vkC, 20), up(vkC, 30), up(vkLCtrl, 45),
+	}
+
+	got := Process(actions, Options{DropKeys: types.Combo{hotkeys.VK_CONTROL, vkR}})
+	if !equalActions(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDropKeysRemovesReleasesAfterHotkey(t *testing.T) {
+	actions := []types.MacroAction{
+		down(vkC, 10), up(vkC, 10),
+		down(vkLCtrl, 5), down(vkR, 7), up(vkR, 3), up(vkLCtrl, 0),
+	}
+	want := []types.MacroAction{down(vkC, 10), up(vkC, 25)}
+
+	got := Process(actions, Options{DropKeys: types.Combo{vkLCtrl, vkR}})
+	if !equalActions(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDropKeysWithoutHotkey(t *testing.T) {
+	actions := []types.MacroAction{down(vkLCtrl, 10), down(vkC, 20), up(vkC, 30), up(vkLCtrl, 0)}
+
+	got := Process(actions, Options{DropKeys: types.Combo{hotkeys.VK_CONTROL, vkR}})
+	if !equalActions(got, actions) {
+		t.Errorf("got %+v, want %+v", got, actions)
+	}
+}
+
+func TestMergeTaps(t *testing.T) {
+	actions := []types.MacroAction{
+		down(vkC, 120), up(vkC, 30),
+		down(vkR, 400), up(vkR, 0),
+	}
+	want := []types.MacroAction{
+		{Kind: types.ActionTap, Keys: []int{vkC}, Delay: 150},
+		{Kind: types.ActionHold, Keys: []int{vkR}, Duration: 400},
+	}
+
+	got := Process(actions, Options{MergeTaps: true})
+	if !equalActions(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestTrimIdle(t *testing.T) {
+	recording := Recording{
+		Events: []hotkeys.Event{
+			{Kind: hotkeys.EventKeyDown, Code: vkC, Time: 1000},
+			{Kind: hotkeys.EventKeyUp, Code: vkC, Time: 1080},
+		},
+		Lead: 2 * time.Second,
+		Tail: 3 * time.Second,
+	}
+
+	actions := Convert(recording)
+	want := []types.MacroAction{
+		{Kind: types.ActionDelay, Delay: 2000},
+		down(vkC, 80), up(vkC, 3000),
+	}
+	if !equalActions(actions, want) {
+		t.Fatalf("Convert() = %+v, want %+v", actions, want)
+	}
+
+	got := Process(actions, Options{TrimIdle: true})
+	want = []types.MacroAction{down(vkC, 80), up(vkC, 0)}
+	if !equalActions(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package recorder
+
+import (
+	"repeat-what-shit/internal/hotkeys"
+	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
+	"repeat-what-shit/internal/utils"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Recorder копит события ввода между Start и Stop
+type Recorder struct {
+	mu        sync.Mutex
+	recording bool
+	events    []hotkeys.Event
+
+	startedAt time.Time
+	firstAt   time.Time
+	lastAt    time.Time
+}
+
+// Recording записанные события и паузы до первого и после последнего из них.
+// Время событий идет по часам источника, а паузы по краям меряются по своим.
+type Recording struct {
+	Events []hotkeys.Event
+	Lead   time.Duration
+	Tail   time.Duration
+}
+
+func New() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = true
+	r.events = nil
+	r.startedAt = time.Now()
+}
+
+func (r *Recorder) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// Add подходит как обработчик HotkeyService.OnEvent, вне записи события игнорируются
+func (r *Recorder) Add(e hotkeys.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return
+	}
+
+	r.lastAt = time.Now()
+	if len(r.events) == 0 {
+		r.firstAt = r.lastAt
+	}
+	r.events = append(r.events, e)
+}
+
+func (r *Recorder) Stop() Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recording := Recording{Events: r.events}
+	if len(r.events) > 0 {
+		recording.Lead = r.firstAt.Sub(r.startedAt)
+		recording.Tail = time.Since(r.lastAt)
+	}
+
+	r.recording = false
+	r.events = nil
+	return recording
+}
+
+// Convert превращает запись в действия макроса. Задержка действия это реальное
+// время до следующего события, пауза до первого события становится ActionDelay,
+// а после последнего задержкой последнего действия. Их убирает TrimIdle.
+func Convert(recording Recording) []types.MacroAction {
+	actions := make([]types.MacroAction, 0, len(recording.Events)+1)
+	times := make([]uint32, 0, len(recording.Events))
+
+	for _, e := range recording.Events {
+		action := types.MacroAction{ID: utils.GenerateID()}
+
+		switch e.Kind {
+		case hotkeys.EventKeyDown:
+			action.Kind = types.ActionDown
+			action.Keys = []int{e.Code}
+		case hotkeys.EventKeyUp:
+			action.Kind = types.ActionUp
+			action.Keys = []int{e.Code}
+		case hotkeys.EventButtonDown, hotkeys.EventButtonUp:
+			button, ok := input.ButtonFromCode(e.Code)
+			if !ok {
+				continue
+			}
+			action.Kind = types.ActionMouseDown
+			if e.Kind == hotkeys.EventButtonUp {
+				action.Kind = types.ActionMouseUp
+			}
+			action.Button = types.MouseButton(button)
+		case hotkeys.EventWheel:
+			action.Kind = types.ActionWheel
+			action.Delta = e.Delta
+		default:
+			continue
+		}
+
+		actions = append(actions, action)
+		times = append(times, e.Time)
+	}
+
+	// Время событий в мс и переполняется примерно раз в 49 дней,
+	// беззнаковая разность это учитывает
+	for i := 0; i+1 < len(actions); i++ {
+		actions[i].Delay = int(times[i+1] - times[i])
+	}
+
+	if len(actions) == 0 {
+		return actions
+	}
+
+	actions[len(actions)-1].Delay = int(recording.Tail.Milliseconds())
+	if lead := int(recording.Lead.Milliseconds()); lead > 0 {
+		idle := types.MacroAction{ID: utils.GenerateID(), Kind: types.ActionDelay, Delay: lead}
+		actions = slices.Insert(actions, 0, idle)
+	}
+
+	return actions
+}
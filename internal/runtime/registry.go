@@ -0,0 +1,231 @@
+package runtime
+
+import (
+	"context"
+	"log"
+	"repeat-what-shit/internal/executor"
+	"repeat-what-shit/internal/types"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Instance запущенный экземпляр макроса
+type Instance struct {
+	ID        int             `json:"id"`
+	MacroID   string          `json:"macro_id"`
+	Type      types.MacroType `json:"type"`
+	StartedAt time.Time       `json:"started_at"`
+}
+
+type instance struct {
+	Instance
+	cancel context.CancelFunc
+	// done закрывается, когда executor вернул управление и отпустил свое
+	done chan struct{}
+}
+
+// Registry владеет всеми запущенными макросами. Все изменения идут под мьютексом,
+// поэтому его можно дергать из обработчиков хоткеев и из самих макросов.
+type Registry struct {
+	executor *executor.Executor
+
+	mu      sync.Mutex
+	nextID  int
+	running map[int]*instance
+	queued  map[string]types.Macro
+	// stopping отмененные экземпляры, которые еще отпускают свои клавиши
+	stopping map[int]*instance
+}
+
+func New(exec *executor.Executor) *Registry {
+	return &Registry{
+		executor: exec,
+		running:  make(map[int]*instance),
+		queued:   make(map[string]types.Macro),
+		stopping: make(map[int]*instance),
+	}
+}
+
+// Start запускает макрос. Toggle и hold идут только по одному: если такой
+// уже идет, вернет false. Для последовательностей решает macro.Concurrency,
+// false значит повторное нажатие проигнорировано.
+// while проверяется перед каждым повтором toggle и hold, nil значит всегда.
+func (r *Registry) Start(macro types.Macro, while func() bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRunning(macro.ID) {
+		r.spawn(macro, while)
+		return true
+	}
+
+	if macro.Type != types.MacroTypeSequence {
+		return false
+	}
+
+	switch macro.Concurrency {
+	case types.ConcurrencyIgnore:
+		return false
+
+	case types.ConcurrencyRestart:
+		r.stop(macro.ID)
+		r.spawn(macro, nil)
+
+	case types.ConcurrencyQueue:
+		r.queued[macro.ID] = macro
+
+	default:
+		r.spawn(macro, nil)
+	}
+	return true
+}
+
+// Toggle останавливает макрос, если он идет, иначе запускает. Проверка и
+// действие идут под одним мьютексом, поэтому нажатие из другой горутины хука
+// не остановит экземпляр, который запустили между ними. true если запущен.
+func (r *Registry) Toggle(macro types.Macro, while func() bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stop(macro.ID) {
+		return false
+	}
+	r.spawn(macro, while)
+	return true
+}
+
+// spawn запускает экземпляр. Если прошлые экземпляры этого макроса еще
+// не вышли, новый ждет их: иначе их отпускание клавиш при отмене
+// попало бы на клавиши, которые только что нажал новый.
+func (r *Registry) spawn(macro types.Macro, while func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.nextID++
+	inst := &instance{
+		Instance: Instance{ID: r.nextID, MacroID: macro.ID, Type: macro.Type, StartedAt: time.Now()},
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	r.running[inst.ID] = inst
+
+	var previous []chan struct{}
+	for _, old := range r.stopping {
+		if old.MacroID == macro.ID {
+			previous = append(previous, old.done)
+		}
+	}
+
+	go func() {
+		defer r.finish(inst)
+		defer close(inst.done)
+
+		for _, done := range previous {
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		var err error
+		if macro.Type == types.MacroTypeSequence {
+			err = r.executor.Run(ctx, macro)
+		} else {
+			err = r.executor.Repeat(ctx, macro, while)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("macro %q stopped: %v", macro.Name, err)
+		}
+	}()
+}
+
+// finish убирает закончившийся экземпляр и запускает отложенный из очереди
+func (r *Registry) finish(inst *instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inst.cancel()
+	delete(r.stopping, inst.ID)
+	if _, exists := r.running[inst.ID]; !exists {
+		return
+	}
+	delete(r.running, inst.ID)
+
+	if macro, queued := r.queued[inst.MacroID]; queued && !r.isRunning(inst.MacroID) {
+		delete(r.queued, inst.MacroID)
+		r.spawn(macro, nil)
+	}
+}
+
+// Stop останавливает все экземпляры макроса, false если ничего не шло
+func (r *Registry) Stop(macroID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stop(macroID)
+}
+
+func (r *Registry) stop(macroID string) bool {
+	delete(r.queued, macroID)
+
+	stopped := false
+	for id, inst := range r.running {
+		if inst.MacroID == macroID {
+			inst.cancel()
+			delete(r.running, id)
+			r.stopping[id] = inst
+			stopped = true
+		}
+	}
+	return stopped
+}
+
+// StopAll останавливает вообще все и отпускает все клавиши, которые макросы
+// оставили нажатыми, включая уже закончившиеся последовательности
+func (r *Registry) StopAll() {
+	r.mu.Lock()
+	var stopped []*instance
+	for id, inst := range r.running {
+		inst.cancel()
+		delete(r.running, id)
+		r.stopping[id] = inst
+	}
+	for _, inst := range r.stopping {
+		stopped = append(stopped, inst)
+	}
+	clear(r.queued)
+	r.mu.Unlock()
+
+	// Пока макрос не вышел, он может успеть нажать что-то еще
+	for _, inst := range stopped {
+		<-inst.done
+	}
+	r.executor.ReleaseAll()
+}
+
+func (r *Registry) IsRunning(macroID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.isRunning(macroID)
+}
+
+func (r *Registry) isRunning(macroID string) bool {
+	for _, inst := range r.running {
+		if inst.MacroID == macroID {
+			return true
+		}
+	}
+	return false
+}
+
+// List возвращает запущенные экземпляры в порядке запуска
+func (r *Registry) List() []Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Instance, 0, len(r.running))
+	for _, inst := range r.running {
+		list = append(list, inst.Instance)
+	}
+	slices.SortFunc(list, func(a, b Instance) int { return a.ID - b.ID })
+	return list
+}
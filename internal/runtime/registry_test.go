@@ -0,0 +1,372 @@
+package runtime
+
+import (
+	"repeat-what-shit/internal/executor"
+	"repeat-what-shit/internal/input"
+	"repeat-what-shit/internal/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+const vkA = 0x41
+
+func newTestRegistry() (*Registry, *input.MemoryInjector) {
+	injector := input.NewMemoryInjector()
+	return New(executor.NewSeeded(injector, 1)), injector
+}
+
+// longMacro жмет клавишу и долго ждет, так что идет, пока его не остановят
+func longMacro(id string, macroType types.MacroType) types.Macro {
+	return types.Macro{
+		ID:   id,
+		Type: macroType,
+		Actions: []types.MacroAction{
+			{Kind: types.ActionDown, Keys: []int{vkA}, Delay: 10_000},
+		},
+	}
+}
+
+func waitStopped(t *testing.T, r *Registry, macroID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for r.IsRunning(macroID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("macro %s is still running", macroID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	r, _ := newTestRegistry()
+	macro := longMacro("a", types.MacroTypeSequence)
+
+	if !r.Start(macro, nil) {
+		t.Fatal("Start() = false for idle macro")
+	}
+	if !r.IsRunning("a") {
+		t.Fatal("macro is not running after Start")
+	}
+
+	if !r.Stop("a") {
+		t.Fatal("Stop() = false for running macro")
+	}
+	if r.IsRunning("a") {
+		t.Fatal("macro is running after Stop")
+	}
+	if r.Stop("a") {
+		t.Fatal("Stop() = true for stopped macro")
+	}
+}
+
+func TestSequenceFinishes(t *testing.T) {
+	r, injector := newTestRegistry()
+	macro := types.Macro{
+		ID:      "a",
+		Actions: []types.MacroAction{{Kind: types.ActionTap, Keys: []int{vkA}}},
+	}
+
+	r.Start(macro, nil)
+	waitStopped(t, r, "a")
+
+	if got := len(injector.Records()); got != 2 {
+		t.Fatalf("got %d records, want key down and up", got)
+	}
+}
+
+func TestToggleRunsOnce(t *testing.T) {
+	r, _ := newTestRegistry()
+	macro := longMacro("a", types.MacroTypeToggle)
+
+	if !r.Start(macro, nil) {
+		t.Fatal("first Start() = false")
+	}
+	if r.Start(macro, nil) {
+		t.Fatal("second Start() of running toggle = true")
+	}
+	if got := len(r.List()); got != 1 {
+		t.Fatalf("got %d instances, want 1", got)
+	}
+	r.StopAll()
+}
+
+func TestToggle(t *testing.T) {
+	r, _ := newTestRegistry()
+	macro := longMacro("a", types.MacroTypeToggle)
+
+	if !r.Toggle(macro, nil) || !r.IsRunning("a") {
+		t.Fatal("first Toggle() did not start the macro")
+	}
+	if r.Toggle(macro, nil) || r.IsRunning("a") {
+		t.Fatal("second Toggle() did not stop the macro")
+	}
+	if !r.Toggle(macro, nil) || !r.IsRunning("a") {
+		t.Fatal("third Toggle() did not start the macro again")
+	}
+	r.StopAll()
+}
+
+// Каждое нажатие переключает ровно один раз, так что после четного числа
+// нажатий из разных горутин макрос стоит, а после нечетного идет
+func TestToggleConcurrent(t *testing.T) {
+	for _, presses := range []int{100, 101} {
+		r, _ := newTestRegistry()
+		macro := longMacro("a", types.MacroTypeToggle)
+
+		var wg sync.WaitGroup
+		for i := 0; i < presses; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Toggle(macro, nil)
+			}()
+		}
+		wg.Wait()
+
+		if want := presses%2 == 1; r.IsRunning("a") != want {
+			t.Errorf("after %d toggles IsRunning = %v, want %v", presses, !want, want)
+		}
+		if got := len(r.List()); got > 1 {
+			t.Errorf("after %d toggles %d instances are running", presses, got)
+		}
+		r.StopAll()
+	}
+}
+
+func TestHoldStopsWhenReleased(t *testing.T) {
+	r, _ := newTestRegistry()
+	macro := types.Macro{
+		ID:      "a",
+		Type:    types.MacroTypeHold,
+		Actions: []types.MacroAction{{Kind: types.ActionTap, Keys: []int{vkA}, Delay: 1}},
+	}
+
+	var mu sync.Mutex
+	held := true
+	r.Start(macro, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return held
+	})
+
+	mu.Lock()
+	held = false
+	mu.Unlock()
+	waitStopped(t, r, "a")
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency types.Concurrency
+		started     bool
+		instances   int
+	}{
+		{"parallel", types.ConcurrencyParallel, true, 2},
+		{"ignore", types.ConcurrencyIgnore, false, 1},
+		{"restart", types.ConcurrencyRestart, true, 1},
+		{"queue", types.ConcurrencyQueue, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRegistry()
+			defer r.StopAll()
+
+			macro := longMacro("a", types.MacroTypeSequence)
+			macro.Concurrency = tt.concurrency
+
+			r.Start(macro, nil)
+			first := r.List()[0].ID
+
+			if got := r.Start(macro, nil); got != tt.started {
+				t.Fatalf("second Start() = %v, want %v", got, tt.started)
+			}
+
+			list := r.List()
+			if len(list) != tt.instances {
+				t.Fatalf("got %d instances, want %d", len(list), tt.instances)
+			}
+			if tt.concurrency == types.ConcurrencyRestart && list[0].ID == first {
+				t.Fatal("restart kept the old instance")
+			}
+		})
+	}
+}
+
+func TestQueueRunsAfterStop(t *testing.T) {
+	r, _ := newTestRegistry()
+	defer r.StopAll()
+
+	macro := longMacro("a", types.MacroTypeSequence)
+	macro.Concurrency = types.ConcurrencyQueue
+
+	r.Start(macro, nil)
+	first := r.List()[0]
+	r.Start(macro, nil)
+
+	cancelInstance(t, r, first.ID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		list := r.List()
+		if len(list) == 1 && list[0].ID != first.ID {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queued run did not start, running %+v", list)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// cancelInstance прерывает экземпляр так, будто он доработал сам:
+// в отличие от Stop очередь при этом сохраняется
+func cancelInstance(t *testing.T, r *Registry, id int) {
+	t.Helper()
+	r.mu.Lock()
+	inst, ok := r.running[id]
+	r.mu.Unlock()
+	if !ok {
+		t.Fatalf("instance %d is not running", id)
+	}
+	inst.cancel()
+}
+
+func TestListOrder(t *testing.T) {
+	r, _ := newTestRegistry()
+	defer r.StopAll()
+
+	for _, id := range []string{"c", "a", "b"} {
+		r.Start(longMacro(id, types.MacroTypeSequence), nil)
+	}
+
+	list := r.List()
+	if len(list) != 3 {
+		t.Fatalf("got %d instances, want 3", len(list))
+	}
+	for i, want := range []string{"c", "a", "b"} {
+		if list[i].MacroID != want {
+			t.Errorf("list[%d] = %s, want %s", i, list[i].MacroID, want)
+		}
+	}
+}
+
+func TestStopAllReleasesKeys(t *testing.T) {
+	r, injector := newTestRegistry()
+
+	// Последовательность закончилась, а клавишу так и оставила нажатой
+	macro := types.Macro{
+		ID:      "a",
+		Actions: []types.MacroAction{{Kind: types.ActionDown, Keys: []int{vkA}}},
+	}
+	r.Start(macro, nil)
+	waitStopped(t, r, "a")
+
+	r.Start(longMacro("b", types.MacroTypeToggle), nil)
+	r.StopAll()
+
+	if len(r.List()) != 0 {
+		t.Fatal("instances left after StopAll")
+	}
+
+	down := 0
+	for _, record := range injector.Records() {
+		switch record.Op {
+		case input.OpKeyDown:
+			down++
+		case input.OpKeyUp:
+			down--
+		}
+	}
+	if down != 0 {
+		t.Fatalf("%d keys left pressed after StopAll", down)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	r, _ := newTestRegistry()
+	defer r.StopAll()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			macro := longMacro("a", types.MacroTypeToggle)
+			for j := 0; j < 50; j++ {
+				if !r.Start(macro, nil) {
+					r.Stop(macro.ID)
+				}
+				r.IsRunning(macro.ID)
+				r.List()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// slowRelease отпускает клавиши с задержкой, как настоящий бэкенд под нагрузкой
+type slowRelease struct {
+	*input.MemoryInjector
+}
+
+func (s slowRelease) KeyUp(key int) error {
+	time.Sleep(5 * time.Millisecond)
+	return s.MemoryInjector.KeyUp(key)
+}
+
+func waitKeyDowns(t *testing.T, injector *input.MemoryInjector, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		downs := 0
+		for _, record := range injector.Records() {
+			if record.Op == input.OpKeyDown {
+				downs++
+			}
+		}
+		if downs >= count {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d key downs, want %d", downs, count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRestartWaitsForRelease(t *testing.T) {
+	injector := input.NewMemoryInjector()
+	r := New(executor.NewSeeded(slowRelease{injector}, 1))
+	defer r.StopAll()
+
+	macro := longMacro("a", types.MacroTypeSequence)
+	macro.Concurrency = types.ConcurrencyRestart
+
+	// Каждый следующий запуск приходит, когда прошлый уже нажал клавишу
+	for i := 1; i <= 5; i++ {
+		r.Start(macro, nil)
+		waitKeyDowns(t, injector, i)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Отпускание старого запуска не должно попасть после нажатия нового
+	records := injector.Records()
+	if len(records) == 0 || records[len(records)-1].Op != input.OpKeyDown {
+		t.Fatalf("last run must leave its key pressed: %+v", records)
+	}
+
+	want := input.OpKeyDown
+	for i, record := range records {
+		if record.Op != want {
+			t.Fatalf("record %d is %v, want %v: %+v", i, record.Op, want, records)
+		}
+		if want == input.OpKeyDown {
+			want = input.OpKeyUp
+		} else {
+			want = input.OpKeyDown
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// AppDataMigrations история формата data.json
+var AppDataMigrations = Migrations{
+	migrateAppDataV0,
+}
+
+// migrateAppDataV0 формат до появления schema_version: у действий не было kind,
+// а activation_keys хранились в порядке нажатия. Теперь kind пишется явно,
+// комбинации отсортированы, пустые списки не null.
+func migrateAppDataV0(doc map[string]any) error {
+	macros, err := objectList(doc, "macros")
+	if err != nil {
+		return err
+	}
+
+	for _, macro := range macros {
+		keys, err := numberList(macro, "activation_keys")
+		if err != nil {
+			return err
+		}
+		slices.SortFunc(keys, func(a, b json.Number) int {
+			x, _ := a.Int64()
+			y, _ := b.Int64()
+			return int(x - y)
+		})
+		macro["activation_keys"] = slices.Compact(keys)
+
+		if macro["include_title"] == nil {
+			macro["include_title"] = []any{}
+		}
+
+		actions, err := objectList(macro, "actions")
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			if _, exists := action["kind"]; !exists {
+				action["kind"] = 0
+			}
+			if action["keys"] == nil {
+				action["keys"] = []any{}
+			}
+		}
+	}
+
+	if doc["settings"] == nil {
+		doc["settings"] = map[string]any{}
+	}
+	return nil
+}
+
+// objectList достает из объекта массив объектов, null и отсутствие дают пустой список
+func objectList(object map[string]any, key string) ([]map[string]any, error) {
+	raw, _ := object[key].([]any)
+	if object[key] != nil && raw == nil {
+		return nil, fmt.Errorf("%s is not an array", key)
+	}
+
+	list := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		element, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s contains a non-object item", key)
+		}
+		list = append(list, element)
+	}
+	return list, nil
+}
+
+func numberList(object map[string]any, key string) ([]json.Number, error) {
+	raw, _ := object[key].([]any)
+	if object[key] != nil && raw == nil {
+		return nil, fmt.Errorf("%s is not an array", key)
+	}
+
+	list := make([]json.Number, 0, len(raw))
+	for _, item := range raw {
+		number, ok := item.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s contains a non-number item", key)
+		}
+		list = append(list, number)
+	}
+	return list, nil
+}
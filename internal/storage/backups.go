@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBackupCount  = 50
+	DefaultBackupMaxAge = 30 * 24 * time.Hour
+
+	backupPrefix     = "data_"
+	backupExt        = ".json"
+	backupTimeLayout = "2006-01-02_15-04-05.000"
+	backupHashLength = 12
+)
+
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// Backups хранит снимки data.json в отдельной папке. Снимок с тем же
+// содержимым второй раз не пишется, старые удаляются по количеству и возрасту.
+type Backups struct {
+	dir string
+
+	mu       sync.Mutex
+	maxCount int
+	maxAge   time.Duration
+}
+
+func NewBackups(dir string) *Backups {
+	return &Backups{dir: dir, maxCount: DefaultBackupCount, maxAge: DefaultBackupMaxAge}
+}
+
+// SetLimits задает сколько снимков и какой давности хранить, 0 значит по умолчанию.
+// Лишние по новым лимитам снимки удаляются сразу, не дожидаясь следующей записи.
+func (b *Backups) SetLimits(maxCount int, maxAge time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maxCount = maxCount
+	if b.maxCount <= 0 {
+		b.maxCount = DefaultBackupCount
+	}
+	b.maxAge = maxAge
+	if b.maxAge <= 0 {
+		b.maxAge = DefaultBackupMaxAge
+	}
+	return b.prune()
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:backupHashLength]
+}
+
+// Snapshot сохраняет содержимое, если оно отличается от последнего снимка,
+// и чистит старые. Возврат к более ранней версии тоже попадает в снимки.
+func (b *Backups) Snapshot(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backups dir: %w", err)
+	}
+
+	hash := contentHash(data)
+	backups, err := b.list()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && backups[0].hash == hash {
+		return nil
+	}
+
+	// Порядок снимков берется из имени, так что время у нового должно быть
+	// строго позже последнего, даже если записи идут чаще раза в миллисекунду
+	createdAt := time.Now().Truncate(time.Millisecond)
+	if len(backups) > 0 && !createdAt.After(backups[0].CreatedAt) {
+		createdAt = backups[0].CreatedAt.Add(time.Millisecond)
+	}
+
+	name := backupPrefix + createdAt.Format(backupTimeLayout) + "_" + hash + backupExt
+	tmpPath, err := writeTemp(filepath.Join(b.dir, name), data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(b.dir, name)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	return b.prune()
+}
+
+type backupFile struct {
+	BackupInfo
+	hash string
+}
+
+// parseBackupName разбирает имя вида data_<время>_<хэш>.json
+func parseBackupName(name string) (time.Time, string, bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+		return time.Time{}, "", false
+	}
+
+	stem := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+	sep := strings.LastIndex(stem, "_")
+	if sep < 0 {
+		return time.Time{}, "", false
+	}
+
+	createdAt, err := time.ParseInLocation(backupTimeLayout, stem[:sep], time.Local)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return createdAt, stem[sep+1:], true
+}
+
+// list снимки от новых к старым, чужие файлы в папке пропускаются
+func (b *Backups) list() ([]backupFile, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups dir: %w", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		createdAt, hash, ok := parseBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{
+			BackupInfo: BackupInfo{Name: entry.Name(), CreatedAt: createdAt, Size: info.Size()},
+			hash:       hash,
+		})
+	}
+
+	slices.SortFunc(backups, func(a, b backupFile) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return backups, nil
+}
+
+// prune удаляет снимки сверх лимита и старше maxAge, самый свежий остается всегда
+func (b *Backups) prune() error {
+	backups, err := b.list()
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		if i >= b.maxCount || time.Since(backup.CreatedAt) > b.maxAge {
+			if err := os.Remove(filepath.Join(b.dir, backup.Name)); err != nil {
+				return fmt.Errorf("failed to remove old backup: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Backups) List() ([]BackupInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	backups, err := b.list()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]BackupInfo, 0, len(backups))
+	for _, backup := range backups {
+		list = append(list, backup.BackupInfo)
+	}
+	return list, nil
+}
+
+// Read содержимое снимка по имени из List
+func (b *Backups) Read(name string) ([]byte, error) {
+	if _, _, ok := parseBackupName(name); !ok || filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid backup name: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// snapshots содержимое снимков от новых к старым
+func snapshots(t *testing.T, b *Backups) []string {
+	t.Helper()
+	list, err := b.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contents []string
+	for _, info := range list {
+		data, err := b.Read(info.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+	return contents
+}
+
+func snapshotAll(t *testing.T, b *Backups, contents ...string) {
+	t.Helper()
+	for _, content := range contents {
+		if err := b.Snapshot([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// oldBackup кладет снимок, сделанный age назад
+func oldBackup(t *testing.T, dir string, age time.Duration, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name := backupPrefix + time.Now().Add(-age).Format(backupTimeLayout) + "_" + contentHash([]byte(content)) + backupExt
+	writeFile(t, filepath.Join(dir, name), content)
+}
+
+func TestSnapshotDedup(t *testing.T) {
+	b := NewBackups(t.TempDir())
+
+	snapshotAll(t, b, "1", "1", "2", "2")
+	if got := snapshots(t, b); !slices.Equal(got, []string{"2", "1"}) {
+		t.Fatalf("snapshots = %v, want [2 1]", got)
+	}
+
+	// Возврат к старому содержимому тоже версия, его надо сохранить
+	snapshotAll(t, b, "1")
+	if got := snapshots(t, b); !slices.Equal(got, []string{"1", "2", "1"}) {
+		t.Errorf("snapshots = %v, want [1 2 1]", got)
+	}
+}
+
+func TestPruneByCount(t *testing.T) {
+	b := NewBackups(t.TempDir())
+	if err := b.SetLimits(3, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotAll(t, b, "1", "2", "3", "4", "5")
+	if got := snapshots(t, b); !slices.Equal(got, []string{"5", "4", "3"}) {
+		t.Errorf("snapshots = %v, want [5 4 3]", got)
+	}
+}
+
+func TestPruneByAge(t *testing.T) {
+	dir := t.TempDir()
+	oldBackup(t, dir, 72*time.Hour, "1")
+	oldBackup(t, dir, 48*time.Hour, "2")
+	oldBackup(t, dir, time.Hour, "3")
+
+	b := NewBackups(dir)
+	if err := b.SetLimits(0, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshots(t, b); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("snapshots = %v, want [3]", got)
+	}
+}
+
+func TestPruneKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	oldBackup(t, dir, 72*time.Hour, "1")
+	oldBackup(t, dir, 48*time.Hour, "2")
+
+	b := NewBackups(dir)
+	if err := b.SetLimits(0, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshots(t, b); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("snapshots = %v, want only the newest [2]", got)
+	}
+}
+
+func TestSetLimitsPrunesImmediately(t *testing.T) {
+	b := NewBackups(t.TempDir())
+	snapshotAll(t, b, "1", "2", "3", "4")
+
+	if err := b.SetLimits(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshots(t, b); !slices.Equal(got, []string{"4", "3"}) {
+		t.Errorf("snapshots = %v, want [4 3] right after SetLimits", got)
+	}
+}
+
+func TestListSkipsForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBackups(dir)
+	snapshotAll(t, b, "1")
+
+	writeFile(t, filepath.Join(dir, "notes.txt"), "x")
+	writeFile(t, filepath.Join(dir, "data_broken.json"), "x")
+	if err := os.Mkdir(filepath.Join(dir, backupPrefix+time.Now().Format(backupTimeLayout)+"_abc"+backupExt), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := snapshots(t, b); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("snapshots = %v, want [1]", got)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	s := NewJsonStorage(path, testData{}).WithBackups(NewBackups(filepath.Join(dir, "backups")))
+
+	for i := 1; i <= 3; i++ {
+		if err := s.Write(testData{Value: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := s.Backups().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("List() = %+v, want 3 snapshots", list)
+	}
+	for i := 1; i < len(list); i++ {
+		if !list[i-1].CreatedAt.After(list[i].CreatedAt) {
+			t.Errorf("List() is not sorted from newest to oldest: %+v", list)
+		}
+	}
+
+	if err := s.RestoreBackup(list[2].Name); err != nil {
+		t.Fatalf("RestoreBackup() error: %v", err)
+	}
+	if s.GetData().Value != 1 {
+		t.Errorf("data = %+v after restore, want the first version", s.GetData())
+	}
+	if got := readFile(t, path); got != `{"value":1}` {
+		t.Errorf("data.json = %s after restore", got)
+	}
+	// Откат сам становится новым снимком, к версии 3 можно вернуться
+	if got := snapshots(t, s.Backups()); !slices.Equal(got, []string{`{"value":1}`, `{"value":3}`, `{"value":2}`, `{"value":1}`}) {
+		t.Errorf("snapshots = %v after restore", got)
+	}
+}
+
+func TestReadRejectsPaths(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "backups")
+	b := NewBackups(dir)
+	snapshotAll(t, b, "1")
+
+	// Файл с правильным именем снаружи папки снимков
+	name := backupPrefix + time.Now().Format(backupTimeLayout) + "_" + contentHash([]byte("secret")) + backupExt
+	writeFile(t, filepath.Join(root, name), "secret")
+
+	for _, name := range []string{
+		"../" + name,
+		"sub/" + name,
+		filepath.Join(root, name),
+		"../data.json",
+		"data.json",
+		"",
+	} {
+		if data, err := b.Read(name); err == nil {
+			t.Errorf("Read(%q) = %q, want an error", name, data)
+		}
+	}
+}
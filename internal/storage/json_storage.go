@@ -4,47 +4,402 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
+	"path/filepath"
+	"sync"
 )
 
+const (
+	backupSuffix  = ".bak"
+	corruptSuffix = ".corrupt"
+)
+
+// JsonStorage можно читать и писать из разных горутин. GetData отдает снимок,
+// который никто больше не меняет: Write подменяет данные целиком.
 type JsonStorage[T any] struct {
-	data     T
 	filePath string
+
+	mu          sync.RWMutex
+	data        T
+	subscribers map[int]func(T)
+	nextSub     int
+
+	// fileMu не дает двум записям или чтениям файла идти одновременно
+	fileMu sync.Mutex
+	// notifyMu берется до отпускания fileMu, так что подписчики получают
+	// изменения строго в том порядке, в каком они попали в файл
+	notifyMu sync.Mutex
+
+	// primaryOK основной файл последний раз читался или писался без ошибок,
+	// только такой можно копировать в .bak, чтобы не затереть рабочую копию битой
+	primaryOK bool
+	recovered error
+
+	migrations Migrations
+	backups    *Backups
+
+	// lastHash содержимое файла, которое мы сами последний раз прочли или записали,
+	// по нему слежение отличает свои записи от чужих правок
+	lastHash string
 }
 
+// Read читает основной файл, а если его нет или он битый, то резервную копию.
+// Если битое все, что есть, стартует с начальных данных, а битый файл
+// откладывает в .corrupt. Что именно произошло, потом отдает Recovered.
+// Файл от более новой версии приложения не битый: Read вернет ErrNewerSchema.
 func (s *JsonStorage[T]) Read() error {
-	if _, err := os.Stat(s.filePath); errors.Is(err, os.ErrNotExist) {
+	s.fileMu.Lock()
+	err := s.read()
+	s.unlockAndNotify(s.GetData())
+	return err
+}
+
+func (s *JsonStorage[T]) read() error {
+	jsonData, err := s.readFile(s.filePath)
+	if err == nil {
+		s.primaryOK = true
+		s.lastHash = contentHash(jsonData)
 		return nil
 	}
+	if isFatal(err) {
+		return err
+	}
 
-	jsonData, err := os.ReadFile(s.filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+	_, backupErr := s.readFile(s.backupPath())
+	if backupErr == nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.recovered = fmt.Errorf("%s is missing, restored from backup", filepath.Base(s.filePath))
+		} else {
+			s.recovered = fmt.Errorf("%s is damaged (%v), restored from backup", filepath.Base(s.filePath), err)
+		}
+		return nil
+	}
+	if isFatal(backupErr) {
+		return backupErr
+	}
+	if errors.Is(err, os.ErrNotExist) && errors.Is(backupErr, os.ErrNotExist) {
+		return nil
 	}
 
-	if err := json.Unmarshal(jsonData, &s.data); err != nil {
-		return fmt.Errorf("failed to unmarshal data: %w", err)
+	// Основного файла нет, а копия битая: она и так не перезапишется,
+	// пока основной не будет записан без ошибок
+	if errors.Is(err, os.ErrNotExist) {
+		s.recovered = fmt.Errorf("%s is missing and its backup is damaged (%v), starting with empty data", filepath.Base(s.filePath), backupErr)
+		return nil
 	}
 
+	corruptPath := s.filePath + corruptSuffix
+	if renameErr := os.Rename(s.filePath, corruptPath); renameErr != nil {
+		return fmt.Errorf("%w, and it could not be moved aside: %w", err, renameErr)
+	}
+	s.recovered = fmt.Errorf("%s is damaged (%v), starting with empty data, the damaged file is saved as %s",
+		filepath.Base(s.filePath), err, filepath.Base(corruptPath))
 	return nil
 }
 
+// isFatal ошибки, которые Read не переживает: сбой чтения и файл от более
+// новой версии. Отсутствие файла и битые данные сюда не относятся.
+func isFatal(err error) bool {
+	if errors.Is(err, ErrNewerSchema) {
+		return true
+	}
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr) && !errors.Is(err, os.ErrNotExist)
+}
+
+func (s *JsonStorage[T]) readFile(path string) ([]byte, error) {
+	jsonData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	data, err := s.decode(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	s.set(data)
+	return jsonData, nil
+}
+
+// decode разбирает документ, предварительно прогнав через миграции
+func (s *JsonStorage[T]) decode(jsonData []byte) (T, error) {
+	var data T
+
+	if s.migrations != nil {
+		var err error
+		jsonData, err = s.migrations.migrate(jsonData)
+		if err != nil {
+			return data, err
+		}
+	}
+
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return data, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	return data, nil
+}
+
+// Recovered не nil, если данные пришлось поднять из резервной копии
+// или начать с пустых
+func (s *JsonStorage[T]) Recovered() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	return s.recovered
+}
+
+func (s *JsonStorage[T]) backupPath() string {
+	return s.filePath + backupSuffix
+}
+
+// Write пишет во временный файл рядом и одним переименованием подменяет им
+// основной, так что основной файл есть на диске в любой момент, старый или новый.
+// Предыдущая версия копируется в .bak. Данные в памяти и подписчики меняются
+// только после успешной записи.
 func (s *JsonStorage[T]) Write(data T) error {
-	s.data = data
+	s.fileMu.Lock()
+	if err := s.write(data); err != nil {
+		s.fileMu.Unlock()
+		return err
+	}
+
+	s.set(data)
+	s.unlockAndNotify(data)
+	return nil
+}
+
+func (s *JsonStorage[T]) write(data T) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
-	if err := os.WriteFile(s.filePath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+
+	if s.migrations != nil {
+		jsonData, err = s.migrations.stamp(jsonData)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmpPath, err := writeTemp(s.filePath, jsonData)
+	if err != nil {
+		return err
+	}
+
+	if s.primaryOK {
+		if err := copyFile(s.filePath, s.backupPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to backup file: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, s.filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	if err := syncDir(filepath.Dir(s.filePath)); err != nil {
+		log.Println("failed to sync data dir:", err)
+	}
+
+	s.primaryOK = true
+	s.lastHash = contentHash(jsonData)
+
+	if s.backups != nil {
+		if err := s.backups.Snapshot(jsonData); err != nil {
+			log.Println("failed to snapshot data:", err)
+		}
+	}
+	return nil
+}
+
+// copyFile копирует через временный файл, так что dst тоже всегда целый
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tmpPath, err := writeTemp(dst, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
 	return nil
 }
 
+func writeTemp(filePath string, data []byte) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	err = file.Chmod(0644)
+	if err == nil {
+		_, err = file.Write(data)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
 func (s *JsonStorage[T]) GetData() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.data
 }
 
+func (s *JsonStorage[T]) set(data T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+}
+
+// unlockAndNotify отпускает fileMu и рассылает data подписчикам. Следующая
+// запись может уже идти, но ее рассылка дождется окончания этой.
+func (s *JsonStorage[T]) unlockAndNotify(data T) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.fileMu.Unlock()
+	s.notify(data)
+}
+
+// notify вызывает подписчиков без s.mu, так что из них можно звать GetData
+func (s *JsonStorage[T]) notify(data T) {
+	s.mu.RLock()
+	subscribers := make([]func(T), 0, len(s.subscribers))
+	for _, fn := range s.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	s.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(data)
+	}
+}
+
+// Subscribe вызывает fn после каждой подмены данных, возвращает отписку.
+// fn зовется из горутины писавшего, вызовы идут по очереди в порядке записей.
+// Писать в хранилище из fn нельзя: Write будет ждать окончания рассылки.
+func (s *JsonStorage[T]) Subscribe(fn func(T)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSub
+	s.nextSub++
+	s.subscribers[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
 func NewJsonStorage[T any](filePath string, initialData T) *JsonStorage[T] {
-	return &JsonStorage[T]{filePath: filePath, data: initialData}
+	return &JsonStorage[T]{filePath: filePath, data: initialData, subscribers: make(map[int]func(T))}
+}
+
+// WithMigrations включает версионирование: Read поднимает старые документы
+// до текущей версии, Write проставляет schema_version
+func (s *JsonStorage[T]) WithMigrations(migrations Migrations) *JsonStorage[T] {
+	s.migrations = migrations
+	return s
+}
+
+// WithBackups включает снимки в папку backups после каждой успешной записи
+func (s *JsonStorage[T]) WithBackups(backups *Backups) *JsonStorage[T] {
+	s.backups = backups
+	return s
+}
+
+func (s *JsonStorage[T]) Backups() *Backups {
+	return s.backups
+}
+
+// RestoreBackup заменяет текущие данные снимком, проводя его через миграции
+// и обычную запись
+func (s *JsonStorage[T]) RestoreBackup(name string) error {
+	if s.backups == nil {
+		return errors.New("backups are not enabled")
+	}
+
+	jsonData, err := s.backups.Read(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.decode(jsonData)
+	if err != nil {
+		return err
+	}
+	return s.Write(data)
+}
+
+// Watch перечитывает файл, когда его меняют снаружи: руками или через git.
+// Свои записи узнаются по содержимому и пропускаются. Новые данные проходят
+// миграции и подменяют текущие только если разобрались без ошибок.
+// onReload получает ошибку разбора или nil после успешной подмены.
+func (s *JsonStorage[T]) Watch(onReload func(error)) (func(), error) {
+	return watchFile(s.filePath, func() {
+		s.fileMu.Lock()
+		data, changed, err := s.reload()
+		if !changed || err != nil {
+			s.fileMu.Unlock()
+			if changed {
+				onReload(err)
+			}
+			return
+		}
+
+		s.unlockAndNotify(data)
+		onReload(nil)
+	})
+}
+
+// reload вызывается под fileMu
+func (s *JsonStorage[T]) reload() (T, bool, error) {
+	var data T
+	jsonData, err := os.ReadFile(s.filePath)
+	if err != nil {
+		// файл мог пропасть на миг между переименованиями, дождемся следующего события
+		return data, false, nil
+	}
+
+	hash := contentHash(jsonData)
+	if hash == s.lastHash {
+		return data, false, nil
+	}
+	s.lastHash = hash
+
+	data, err = s.decode(jsonData)
+	if err != nil {
+		// на диске теперь битый файл, следующая запись не должна унести его в .bak
+		s.primaryOK = false
+		return data, true, err
+	}
+
+	s.set(data)
+	s.primaryOK = true
+
+	if s.backups != nil {
+		if err := s.backups.Snapshot(jsonData); err != nil {
+			log.Println("failed to snapshot data:", err)
+		}
+	}
+	return data, true, nil
 }
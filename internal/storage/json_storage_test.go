@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Подписчик должен увидеть записи в том же порядке, в каком они легли в файл,
+// иначе после гонки двух Write у него останется не последнее состояние
+func TestSubscribeOrder(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		s := NewJsonStorage(filepath.Join(t.TempDir(), "data.json"), 0)
+
+		var mu sync.Mutex
+		var last int
+		s.Subscribe(func(value int) {
+			// медленный подписчик, чтобы рассылки успели наложиться
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			last = value
+		})
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := s.Write(i); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		reread := NewJsonStorage(s.filePath, 0)
+		if err := reread.Read(); err != nil {
+			t.Fatal(err)
+		}
+
+		mu.Lock()
+		got := last
+		mu.Unlock()
+		if got != s.GetData() || got != reread.GetData() {
+			t.Fatalf("subscriber ended with %d, storage has %d, file has %d", got, s.GetData(), reread.GetData())
+		}
+	}
+}
+
+type testData struct {
+	Value int `json:"value"`
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestWriteKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	s := NewJsonStorage(path, testData{})
+
+	for i := 1; i <= 3; i++ {
+		if err := s.Write(testData{Value: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readFile(t, path); got != `{"value":3}` {
+		t.Errorf("data.json = %s", got)
+	}
+	if got := readFile(t, path+backupSuffix); got != `{"value":2}` {
+		t.Errorf("data.json.bak = %s, want the previous version", got)
+	}
+
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(tmps) != 0 {
+		t.Errorf("temp files left behind: %v", tmps)
+	}
+}
+
+func TestWriteFailureKeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	s := NewJsonStorage[any](path, 1)
+	if err := s.Write(2); err != nil {
+		t.Fatal(err)
+	}
+
+	notified := false
+	s.Subscribe(func(any) { notified = true })
+
+	// Функцию нельзя превратить в JSON, запись падает до файла
+	if err := s.Write(func() {}); err == nil {
+		t.Fatal("Write() of a func succeeded")
+	}
+	if s.GetData() != 2 {
+		t.Errorf("data = %v after a failed write, want 2", s.GetData())
+	}
+	if notified {
+		t.Error("subscribers were notified about a failed write")
+	}
+	if got := readFile(t, path); got != "2" {
+		t.Errorf("data.json = %s after a failed write", got)
+	}
+}
+
+func TestReadRestoresFromBackup(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+		want    string
+	}{
+		{"damaged", `{"value":`, "is damaged"},
+		{"missing", "", "is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.json")
+			if tt.primary != "" {
+				writeFile(t, path, tt.primary)
+			}
+			writeFile(t, path+backupSuffix, `{"value":7}`)
+
+			s := NewJsonStorage(path, testData{})
+			if err := s.Read(); err != nil {
+				t.Fatalf("Read() error: %v", err)
+			}
+			if s.GetData().Value != 7 {
+				t.Errorf("data = %+v, want the backup", s.GetData())
+			}
+			if err := s.Recovered(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Recovered() = %v, want %q", err, tt.want)
+			}
+
+			// Битый основной файл не должен затереть рабочую копию
+			if err := s.Write(testData{Value: 8}); err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, path+backupSuffix); got != `{"value":7}` {
+				t.Errorf("data.json.bak = %s, want the old backup", got)
+			}
+		})
+	}
+}
+
+func TestReadQuarantinesCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	writeFile(t, path, `{"value":`)
+	writeFile(t, path+backupSuffix, `not json`)
+
+	s := NewJsonStorage(path, testData{Value: -1})
+	if err := s.Read(); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if s.GetData().Value != -1 {
+		t.Errorf("data = %+v, want the initial data", s.GetData())
+	}
+	if got := readFile(t, path+corruptSuffix); got != `{"value":` {
+		t.Errorf("data.json.corrupt = %q, want the damaged file", got)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("damaged data.json was not moved aside: %v", err)
+	}
+	if err := s.Recovered(); err == nil || !strings.Contains(err.Error(), "starting with empty data") {
+		t.Errorf("Recovered() = %v", err)
+	}
+}
+
+func TestReadNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	const newer = `{"schema_version":5,"value":1}`
+	writeFile(t, path, newer)
+	writeFile(t, path+backupSuffix, `{"schema_version":1,"value":2}`)
+
+	s := NewJsonStorage(path, testData{}).WithMigrations(Migrations{func(map[string]any) error { return nil }})
+	if err := s.Read(); !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("Read() error = %v, want ErrNewerSchema", err)
+	}
+	if got := readFile(t, path); got != newer {
+		t.Errorf("data.json changed to %s", got)
+	}
+	if _, err := os.Stat(path + corruptSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Error("file from a newer version was quarantined")
+	}
+}
+
+func TestReadWithoutFiles(t *testing.T) {
+	s := NewJsonStorage(filepath.Join(t.TempDir(), "data.json"), testData{Value: 3})
+	if err := s.Read(); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if s.GetData().Value != 3 || s.Recovered() != nil {
+		t.Errorf("data = %+v, Recovered() = %v; want initial data and no warning", s.GetData(), s.Recovered())
+	}
+}
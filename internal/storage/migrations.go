@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const schemaVersionKey = "schema_version"
+
+// ErrNewerSchema документ записан более новой версией приложения. Он не битый,
+// поэтому его нельзя ни поднимать из копии, ни перезаписывать.
+var ErrNewerSchema = errors.New("written by a newer version of the app")
+
+// Migration поднимает разобранный JSON документ на одну версию вверх
+type Migration func(doc map[string]any) error
+
+// Migrations цепочка миграций: i-я переводит документ с версии i на i+1,
+// так что текущая версия схемы равна длине цепочки. Документы без
+// schema_version считаются версией 0.
+type Migrations []Migration
+
+func (m Migrations) Version() int {
+	return len(m)
+}
+
+// Apply по шагам доводит документ до текущей версии
+func (m Migrations) Apply(doc map[string]any) error {
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return err
+	}
+
+	if version > m.Version() {
+		return fmt.Errorf("schema version %d is newer than supported %d, %w", version, m.Version(), ErrNewerSchema)
+	}
+
+	for ; version < m.Version(); version++ {
+		if err := m[version](doc); err != nil {
+			return fmt.Errorf("failed to migrate from schema version %d: %w", version, err)
+		}
+	}
+
+	doc[schemaVersionKey] = m.Version()
+	return nil
+}
+
+func schemaVersion(doc map[string]any) (int, error) {
+	raw, exists := doc[schemaVersionKey]
+	if !exists || raw == nil {
+		return 0, nil
+	}
+
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema version is not a number: %v", raw)
+	}
+
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid schema version: %v", raw)
+	}
+	return int(version), nil
+}
+
+// migrate прогоняет JSON через миграции и отдает обновленный документ
+func (m Migrations) migrate(jsonData []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+
+	if err := m.Apply(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// stamp проставляет текущую версию схемы в сериализованный документ
+func (m Migrations) stamp(jsonData []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, fmt.Errorf("failed to stamp schema version: %w", err)
+	}
+
+	version, _ := json.Marshal(m.Version())
+	doc[schemaVersionKey] = version
+	return json.Marshal(doc)
+}
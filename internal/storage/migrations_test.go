@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"repeat-what-shit/internal/types"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// Для каждого исторического формата в testdata/app_data лежит документ
+// <имя>.json и то, во что его превращают миграции, <имя>.golden
+func TestAppDataMigrationsGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "app_data", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			jsonData, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migrated, err := AppDataMigrations.migrate(jsonData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+			if err := json.Indent(&got, migrated, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			got.WriteByte('\n')
+
+			goldenPath := strings.TrimSuffix(input, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(goldenPath, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("migrated %s differs from %s:\n%s", input, goldenPath, got.String())
+			}
+
+			// Результат должен без остатка ложиться на текущие типы
+			decoder := json.NewDecoder(bytes.NewReader(migrated))
+			decoder.DisallowUnknownFields()
+			var data types.AppData
+			if err := decoder.Decode(&data); err != nil {
+				t.Fatalf("migrated document does not match types.AppData: %v", err)
+			}
+			if data.SchemaVersion != AppDataMigrations.Version() {
+				t.Errorf("schema_version = %d, want %d", data.SchemaVersion, AppDataMigrations.Version())
+			}
+		})
+	}
+}
+
+func TestMigrationsApply(t *testing.T) {
+	var steps []int
+	migrations := Migrations{
+		func(doc map[string]any) error {
+			steps = append(steps, 0)
+			return nil
+		},
+		func(doc map[string]any) error {
+			steps = append(steps, 1)
+			return nil
+		},
+	}
+
+	doc := map[string]any{schemaVersionKey: json.Number("1")}
+	if err := migrations.Apply(doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || steps[0] != 1 {
+		t.Errorf("applied steps %v, want only [1]", steps)
+	}
+	if doc[schemaVersionKey] != 2 {
+		t.Errorf("schema_version = %v, want 2", doc[schemaVersionKey])
+	}
+
+	steps = nil
+	if err := migrations.Apply(map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Errorf("document without version got steps %v, want [0 1]", steps)
+	}
+}
+
+func TestMigrationsApplyErrors(t *testing.T) {
+	failing := errors.New("broken")
+	migrations := Migrations{func(doc map[string]any) error { return failing }}
+
+	tests := []struct {
+		name    string
+		version any
+		want    string
+	}{
+		{"newer than supported", json.Number("2"), "schema version 2 is newer than supported 1"},
+		{"not a number", "1", "schema version is not a number"},
+		{"negative", json.Number("-1"), "invalid schema version"},
+		{"fraction", json.Number("0.5"), "invalid schema version"},
+		{"migration fails", json.Number("0"), "failed to migrate from schema version 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := migrations.Apply(map[string]any{schemaVersionKey: tt.version})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Apply() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateAppDataV0Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"macros not an array", `{"macros": {}}`},
+		{"macro not an object", `{"macros": [1]}`},
+		{"activation keys not numbers", `{"macros": [{"activation_keys": ["a"]}]}`},
+		{"actions not an array", `{"macros": [{"actions": "a"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AppDataMigrations.migrate([]byte(tt.doc)); err == nil {
+				t.Fatal("migrate() succeeded on a malformed document")
+			}
+		})
+	}
+}
+
+func TestStamp(t *testing.T) {
+	stamped, err := AppDataMigrations.stamp([]byte(`{"macros":[],"schema_version":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(stamped, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc[schemaVersionKey] != float64(AppDataMigrations.Version()) {
+		t.Fatalf("schema_version = %v, want %d", doc[schemaVersionKey], AppDataMigrations.Version())
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package storage
+
+import "os"
+
+// syncDir сбрасывает на диск саму папку, иначе после сбоя питания
+// переименование файла в ней может не сохраниться
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
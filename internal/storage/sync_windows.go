@@ -0,0 +1,7 @@
+package storage
+
+// syncDir на Windows не нужен и невозможен: папку нельзя открыть на запись,
+// а переименование NTFS журналирует сама
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrWatchUnsupported = errors.New("file watching is not supported on this platform")
+
+// Редактор или git могут записать файл в несколько приемов,
+// перечитываем только когда события утихнут
+const watchDebounce = 200 * time.Millisecond
+
+// debounce откладывает fn, пока вызовы идут чаще watchDebounce
+func debounce(fn func()) func() {
+	var timer *time.Timer
+	return func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(watchDebounce, fn)
+	}
+}
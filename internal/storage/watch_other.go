@@ -0,0 +1,7 @@
+//go:build !windows && !linux
+
+package storage
+
+func watchFile(path string, changed func()) (func(), error) {
+	return nil, ErrWatchUnsupported
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watchStorage пишет начальные версии и включает слежение, результаты
+// перечитываний приходят в канал
+func watchStorage(t *testing.T, values ...int) (*JsonStorage[testData], string, <-chan error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.json")
+	s := NewJsonStorage(path, testData{})
+	for _, value := range values {
+		if err := s.Write(testData{Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloads := make(chan error, 16)
+	stop, err := s.Watch(func(err error) { reloads <- err })
+	if errors.Is(err, ErrWatchUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	return s, path, reloads
+}
+
+func waitReload(t *testing.T, reloads <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-reloads:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("external edit was not picked up")
+		return nil
+	}
+}
+
+func TestWatchPicksUpExternalEdit(t *testing.T) {
+	s, path, reloads := watchStorage(t, 1)
+
+	notified := make(chan testData, 1)
+	s.Subscribe(func(data testData) { notified <- data })
+
+	writeFile(t, path, `{"value":5}`)
+	if err := waitReload(t, reloads); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if s.GetData().Value != 5 {
+		t.Errorf("data = %+v, want the external edit", s.GetData())
+	}
+	select {
+	case data := <-notified:
+		if data.Value != 5 {
+			t.Errorf("subscriber got %+v", data)
+		}
+	default:
+		t.Error("subscribers were not notified about the external edit")
+	}
+}
+
+func TestWatchIgnoresOwnWrites(t *testing.T) {
+	s, path, reloads := watchStorage(t, 1)
+
+	if err := s.Write(testData{Value: 2}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Fatalf("own write was reloaded (err %v)", err)
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// Слежение после своей записи продолжает работать
+	writeFile(t, path, `{"value":3}`)
+	if err := waitReload(t, reloads); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if s.GetData().Value != 3 {
+		t.Errorf("data = %+v, want the external edit", s.GetData())
+	}
+}
+
+func TestWatchRejectsInvalidEdit(t *testing.T) {
+	s, path, reloads := watchStorage(t, 1, 2)
+
+	notified := false
+	s.Subscribe(func(testData) { notified = true })
+
+	writeFile(t, path, `{"value":`)
+	if err := waitReload(t, reloads); err == nil {
+		t.Fatal("invalid edit was loaded without an error")
+	}
+	if s.GetData().Value != 2 {
+		t.Errorf("data = %+v, want the previous data", s.GetData())
+	}
+	if notified {
+		t.Error("subscribers were notified about an invalid edit")
+	}
+
+	// Битый файл не должен занять место рабочей копии
+	if err := s.Write(testData{Value: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path+backupSuffix); got != `{"value":1}` {
+		t.Errorf("data.json.bak = %s, want it untouched", got)
+	}
+	if got := readFile(t, path); got != `{"value":3}` {
+		t.Errorf("data.json = %s", got)
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32            = syscall.NewLazyDLL("kernel32.dll")
+	cancelIoEx          = kernel32.NewProc("CancelIoEx")
+	createEvent         = kernel32.NewProc("CreateEventW")
+	getOverlappedResult = kernel32.NewProc("GetOverlappedResult")
+
+	watchFilter = uint32(syscall.FILE_NOTIFY_CHANGE_LAST_WRITE | syscall.FILE_NOTIFY_CHANGE_FILE_NAME)
+)
+
+// watchFile следит за папкой файла через ReadDirectoryChangesW: атомарная
+// запись подменяет файл переименованием, поэтому смотрим по имени
+func watchFile(path string, changed func()) (func(), error) {
+	dir, err := syscall.UTF16PtrFromString(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := syscall.CreateFile(
+		dir,
+		syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_BACKUP_SEMANTICS|syscall.FILE_FLAG_OVERLAPPED,
+		0,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Dir(path), err)
+	}
+
+	event, _, err := createEvent.Call(0, 1, 0, 0)
+	if event == 0 {
+		syscall.CloseHandle(handle)
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+	overlapped := &syscall.Overlapped{HEvent: syscall.Handle(event)}
+
+	name := filepath.Base(path)
+	notify := debounce(changed)
+
+	go func() {
+		defer syscall.CloseHandle(handle)
+		defer syscall.CloseHandle(overlapped.HEvent)
+
+		buf := make([]byte, 64*1024)
+		for {
+			err := syscall.ReadDirectoryChanges(handle, &buf[0], uint32(len(buf)), false, watchFilter, nil, overlapped, 0)
+			if err != nil && err != syscall.ERROR_IO_PENDING {
+				return
+			}
+
+			// после отмены через CancelIoEx вернет ошибку ERROR_OPERATION_ABORTED
+			var n uint32
+			ok, _, _ := getOverlappedResult.Call(uintptr(handle), uintptr(unsafe.Pointer(overlapped)), uintptr(unsafe.Pointer(&n)), 1)
+			if ok == 0 {
+				return
+			}
+
+			for offset := uint32(0); offset < n; {
+				info := (*syscall.FileNotifyInformation)(unsafe.Pointer(&buf[offset]))
+				nameLen := info.FileNameLength / 2
+				fileName := syscall.UTF16ToString(unsafe.Slice(&info.FileName, nameLen))
+
+				if strings.EqualFold(fileName, name) && info.Action != syscall.FILE_ACTION_REMOVED {
+					notify()
+				}
+
+				if info.NextEntryOffset == 0 {
+					break
+				}
+				offset += info.NextEntryOffset
+			}
+		}
+	}()
+
+	return func() { cancelIoEx.Call(uintptr(handle), uintptr(unsafe.Pointer(overlapped))) }, nil
+}
@@ -1,5 +1,16 @@
 package types
 
 type AppData struct {
-	Macros []Macro `json:"macros"`
+	// SchemaVersion проставляет хранилище при записи, см. storage.AppDataMigrations
+	SchemaVersion int      `json:"schema_version"`
+	Macros        []Macro  `json:"macros"`
+	Settings      Settings `json:"settings"`
+}
+
+type Settings struct {
+	// PanicKeys останавливает все запущенные макросы, пустая комбинация отключает
+	PanicKeys Combo `json:"panic_keys"`
+	// Сколько снимков data.json хранить и не старше скольких дней, 0 по умолчанию
+	BackupCount int `json:"backup_count"`
+	BackupDays  int `json:"backup_days"`
 }
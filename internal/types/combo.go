@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Combo набор одновременно нажатых клавиш, порядок не важен.
+// В каноничной форме коды отсортированы по возрастанию и не повторяются.
+type Combo []int
+
+func NewCombo(keys ...int) Combo {
+	return Combo(keys).Canonical()
+}
+
+func (c Combo) Canonical() Combo {
+	canonical := slices.Clone(c)
+	slices.Sort(canonical)
+	return slices.Compact(canonical)
+}
+
+// Equal сравнивает комбинации как множества
+func (c Combo) Equal(other Combo) bool {
+	return slices.Equal(c.Canonical(), other.Canonical())
+}
+
+// Contains сообщает, что все клавиши other есть в c
+func (c Combo) Contains(other Combo) bool {
+	for _, key := range other {
+		if !slices.Contains(c, key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Combo) IsEmpty() bool {
+	return len(c) == 0
+}
+
+// String возвращает стабильную запись вида "17+70", одинаковую для любого порядка клавиш
+func (c Combo) String() string {
+	canonical := c.Canonical()
+	parts := make([]string, len(canonical))
+	for i, key := range canonical {
+		parts[i] = strconv.Itoa(key)
+	}
+	return strings.Join(parts, "+")
+}
+
+func ParseCombo(s string) (Combo, error) {
+	if s == "" {
+		return Combo{}, nil
+	}
+
+	parts := strings.Split(s, "+")
+	combo := make(Combo, 0, len(parts))
+	for _, part := range parts {
+		key, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in combo %q", part, s)
+		}
+		combo = append(combo, key)
+	}
+	return combo.Canonical(), nil
+}
+
+// UnmarshalJSON принимает и массив кодов, и строку вида "17+70",
+// которую удобнее писать руками в data.json. Результат всегда каноничный.
+func (c *Combo) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		combo, err := ParseCombo(s)
+		if err != nil {
+			return err
+		}
+		*c = combo
+		return nil
+	}
+
+	var keys []int
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	*c = Combo(keys).Canonical()
+	return nil
+}
@@ -0,0 +1,170 @@
+package types
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name  string
+		combo Combo
+		want  Combo
+	}{
+		{"nil", nil, nil},
+		{"empty", Combo{}, Combo{}},
+		{"single", Combo{65}, Combo{65}},
+		{"sorted", Combo{17, 65}, Combo{17, 65}},
+		{"unsorted", Combo{65, 17, 16}, Combo{16, 17, 65}},
+		{"duplicates", Combo{65, 17, 65, 17}, Combo{17, 65}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.combo.Canonical()
+			if !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("Canonical(%#v) = %#v, want %#v", tt.combo, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalDoesNotModify(t *testing.T) {
+	combo := Combo{65, 17, 65}
+	combo.Canonical()
+	if !slices.Equal(combo, Combo{65, 17, 65}) {
+		t.Errorf("Canonical modified the receiver: %v", combo)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b Combo
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, Combo{}, true},
+		{Combo{17, 65}, Combo{65, 17}, true},
+		{Combo{17, 65, 65}, Combo{65, 17}, true},
+		{Combo{17, 65}, Combo{17}, false},
+		{Combo{17}, Combo{65}, false},
+		{Combo{17}, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Equal(tt.b); got != tt.want {
+			t.Errorf("%v.Equal(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := tt.b.Equal(tt.a); got != tt.want {
+			t.Errorf("%v.Equal(%v) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		c, other Combo
+		want     bool
+	}{
+		{nil, nil, true},
+		{Combo{17}, nil, true},
+		{nil, Combo{17}, false},
+		{Combo{65, 17}, Combo{17}, true},
+		{Combo{65, 17}, Combo{17, 65}, true},
+		{Combo{17, 65}, Combo{65, 65}, true},
+		{Combo{17}, Combo{17, 65}, false},
+		{Combo{16, 17}, Combo{65}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.Contains(tt.other); got != tt.want {
+			t.Errorf("%v.Contains(%v) = %v, want %v", tt.c, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestComboString(t *testing.T) {
+	tests := []struct {
+		combo Combo
+		want  string
+	}{
+		{nil, ""},
+		{Combo{65}, "65"},
+		{Combo{65, 17}, "17+65"},
+		{Combo{17, 65, 17}, "17+65"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.combo.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.combo, got, tt.want)
+		}
+	}
+}
+
+func TestParseCombo(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Combo
+		wantErr bool
+	}{
+		{"", Combo{}, false},
+		{"65", Combo{65}, false},
+		{"65+17", Combo{17, 65}, false},
+		{"17+65+17", Combo{17, 65}, false},
+		{"17+", nil, true},
+		{"ctrl+65", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCombo(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCombo(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseCombo(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+
+	for _, combo := range []Combo{{65}, {17, 65}, {16, 17, 18, 65}} {
+		parsed, err := ParseCombo(combo.String())
+		if err != nil || !parsed.Equal(combo) {
+			t.Errorf("ParseCombo(%q) = %v, %v; want %v", combo.String(), parsed, err, combo)
+		}
+	}
+}
+
+func TestComboUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Combo
+		wantErr bool
+	}{
+		{`[65, 17, 65]`, Combo{17, 65}, false},
+		{`[]`, Combo{}, false},
+		{`"65+17"`, Combo{17, 65}, false},
+		{`""`, Combo{}, false},
+		{`"a+b"`, nil, true},
+		{`{}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		var got Combo
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.json, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.json, got, tt.want)
+		}
+	}
+
+	// Пустая комбинация должна сохраняться массивом, а не null
+	var empty Combo
+	json.Unmarshal([]byte(`[]`), &empty)
+	if data, _ := json.Marshal(empty); string(data) != "[]" {
+		t.Errorf("empty combo round trip = %s, want []", data)
+	}
+}
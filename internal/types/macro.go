@@ -8,18 +8,96 @@ const (
 	MacroTypeHold
 )
 
+// Concurrency что делать, если последовательность нажали, пока она еще идет.
+// Toggle и hold живут по своим правилам: повторное нажатие выключает toggle,
+// а hold и так идет, пока держат клавиши.
+type Concurrency int
+
+const (
+	ConcurrencyParallel Concurrency = iota
+	ConcurrencyIgnore
+	ConcurrencyRestart
+	// ConcurrencyQueue запускает еще один раз после текущего, больше одного не копит
+	ConcurrencyQueue
+)
+
+// ActionKind определяет, что действие делает с клавишами. Нулевое значение
+// это нажатие с отпусканием, поэтому старые data.json без kind читаются как раньше.
+type ActionKind int
+
+const (
+	ActionTap ActionKind = iota
+	ActionDown
+	ActionUp
+	ActionHold
+	ActionDelay
+	ActionClick
+	ActionMouseDown
+	ActionMouseUp
+	ActionWheel
+	ActionMove
+	ActionMoveTo
+	ActionText
+)
+
+// MouseButton значения совпадают с input.MouseButton
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseRight
+	MouseMiddle
+	MouseX1
+	MouseX2
+)
+
 type MacroAction struct {
-	ID    string `json:"id"`
-	Keys  []int  `json:"keys"`
-	Delay int    `json:"delay"`
+	ID   string     `json:"id"`
+	Kind ActionKind `json:"kind"`
+	Keys []int      `json:"keys"`
+	// Duration сколько мс держать клавиши для ActionHold
+	Duration int         `json:"duration"`
+	Button   MouseButton `json:"button"`
+	// X и Y смещение для ActionMove или координаты виртуального рабочего стола для ActionMoveTo
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Delta int `json:"delta"`
+	// Text печатается посимвольно для ActionText, CharDelay пауза между символами в мс
+	Text      string    `json:"text"`
+	CharDelay int       `json:"char_delay"`
+	InputMode InputMode `json:"input_mode"`
+	Delay     int       `json:"delay"`
+	// DelayMax больше Delay включает случайную паузу из диапазона [Delay, DelayMax],
+	// иначе DelayStdDev задает нормальный разброс вокруг Delay
+	DelayMax    int `json:"delay_max"`
+	DelayStdDev int `json:"delay_std_dev"`
 }
 
+// InputMode способ отправки клавиш. У действия InputModeDefault означает
+// режим макроса, у макроса обычные virtual-key коды.
+type InputMode int
+
+const (
+	InputModeDefault InputMode = iota
+	InputModeVirtualKey
+	InputModeScancode
+)
+
 type Macro struct {
 	ID             string        `json:"id"`
 	Disabled       bool          `json:"disabled"`
 	Name           string        `json:"name"`
-	ActivationKeys []int         `json:"activation_keys"`
+	ActivationKeys Combo         `json:"activation_keys"`
 	Type           MacroType     `json:"type"`
 	Actions        []MacroAction `json:"actions"`
 	IncludeTitle   []string      `json:"include_title"`
+	InputMode      InputMode     `json:"input_mode"`
+	Concurrency    Concurrency   `json:"concurrency"`
+	// Humanize случайно меняет все паузы и удержания на +-столько процентов
+	Humanize int `json:"humanize"`
+
+	// Ограничения повторов для toggle и hold, 0 значит без ограничения
+	RepeatCount            int `json:"repeat_count"`
+	MaxDuration            int `json:"max_duration"`
+	IntervalBetweenRepeats int `json:"interval_between_repeats"`
 }
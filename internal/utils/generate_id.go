@@ -0,0 +1,12 @@
+package utils
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// GenerateID делает id в том же формате, что и generateId во фронте
+func GenerateID() string {
+	return strconv.FormatInt(time.Now().UnixMilli(), 10) + strconv.FormatInt(rand.Int63(), 36)
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+type WindowInfo struct {
+	Handle     uintptr `json:"handle"`
+	Process    string  `json:"process"`
+	IconBase64 string  `json:"iconBase64"`
+}
+
+// На Linux окна и активный процесс доступны только через X сервер
+// (в том числе XWayland), без $DISPLAY фильтр по процессу не работает
+var x11 struct {
+	once sync.Once
+	conn *xgb.Conn
+	root xproto.Window
+}
+
+func x11Conn() (*xgb.Conn, xproto.Window) {
+	x11.once.Do(func() {
+		if os.Getenv("DISPLAY") == "" {
+			return
+		}
+
+		conn, err := xgb.NewConnDisplay("")
+		if err != nil {
+			return
+		}
+
+		x11.conn = conn
+		x11.root = xproto.Setup(conn).DefaultScreen(conn).Root
+	})
+	return x11.conn, x11.root
+}
+
+func getProperty(conn *xgb.Conn, window xproto.Window, name string) []byte {
+	atom, err := xproto.InternAtom(conn, true, uint16(len(name)), name).Reply()
+	if err != nil || atom.Atom == xproto.AtomNone {
+		return nil
+	}
+
+	reply, err := xproto.GetProperty(conn, false, window, atom.Atom, xproto.GetPropertyTypeAny, 0, 1<<20).Reply()
+	if err != nil || reply.Format != 32 {
+		return nil
+	}
+
+	return reply.Value
+}
+
+func cardinals(raw []byte) []uint32 {
+	values := make([]uint32, len(raw)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return values
+}
+
+func windowProcessName(conn *xgb.Conn, window xproto.Window) string {
+	pid := cardinals(getProperty(conn, window, "_NET_WM_PID"))
+	if len(pid) == 0 {
+		return ""
+	}
+
+	proc := filepath.Join("/proc", strconv.FormatUint(uint64(pid[0]), 10))
+	if exe, err := os.Readlink(filepath.Join(proc, "exe")); err == nil {
+		return filepath.Base(exe)
+	}
+
+	comm, err := os.ReadFile(filepath.Join(proc, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// windowIcon берет из _NET_WM_ICON вариант, ближайший к 32x32
+func windowIcon(conn *xgb.Conn, window xproto.Window) string {
+	data := cardinals(getProperty(conn, window, "_NET_WM_ICON"))
+
+	var best []uint32
+	var bestW, bestH int
+	for len(data) >= 2 {
+		w, h := int(data[0]), int(data[1])
+		if w <= 0 || h <= 0 || len(data) < 2+w*h {
+			break
+		}
+
+		if best == nil || absInt(w-32) < absInt(bestW-32) {
+			best, bestW, bestH = data[2:2+w*h], w, h
+		}
+		data = data[2+w*h:]
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, bestW, bestH))
+	for i, argb := range best {
+		img.Set(i%bestW, i/bestW, color.NRGBA{
+			R: uint8(argb >> 16),
+			G: uint8(argb >> 8),
+			B: uint8(argb),
+			A: uint8(argb >> 24),
+		})
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func GetWindows() []WindowInfo {
+	conn, root := x11Conn()
+	if conn == nil {
+		return nil
+	}
+
+	var windows []WindowInfo
+	seenProcesses := make(map[string]bool)
+
+	for _, id := range cardinals(getProperty(conn, root, "_NET_CLIENT_LIST")) {
+		window := xproto.Window(id)
+
+		process := windowProcessName(conn, window)
+		if process == "" || seenProcesses[process] {
+			continue
+		}
+		seenProcesses[process] = true
+
+		windows = append(windows, WindowInfo{
+			Handle:     uintptr(window),
+			Process:    process,
+			IconBase64: windowIcon(conn, window),
+		})
+	}
+
+	return windows
+}
+
+func GetActiveProcessName() string {
+	conn, root := x11Conn()
+	if conn == nil {
+		return ""
+	}
+
+	active := cardinals(getProperty(conn, root, "_NET_ACTIVE_WINDOW"))
+	if len(active) == 0 || active[0] == 0 {
+		return ""
+	}
+
+	return windowProcessName(conn, xproto.Window(active[0]))
+}
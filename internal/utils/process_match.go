@@ -3,7 +3,7 @@ package utils
 import "strings"
 
 func IsWindowMatch(processName string, includeTitle []string) bool {
-	if processName == "repeat-what-shit.exe" {
+	if processName == "repeat-what-shit.exe" || processName == "repeat-what-shit" {
 		return false
 	}
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"repeat-what-shit/internal"
 	"repeat-what-shit/internal/consts"
+	"repeat-what-shit/internal/hotkeys"
 	"repeat-what-shit/internal/storage"
 	"repeat-what-shit/internal/types"
 	"repeat-what-shit/internal/utils"
@@ -39,14 +40,22 @@ func main() {
 
 	utils.Catch(utils.CreateAppDirIfNotExists())
 
-	appData := storage.NewJsonStorage(fmt.Sprintf("%s/data.json", appDir), types.AppData{})
+	appData := storage.NewJsonStorage(fmt.Sprintf("%s/data.json", appDir), types.AppData{}).
+		WithMigrations(storage.AppDataMigrations)
 	utils.Catch(appData.Read())
+	appData.WithBackups(storage.NewBackups(fmt.Sprintf("%s/backups", appDir)))
+
+	injector, source, err := hotkeys.NewBackends()
+	utils.Catch(err)
 
 	a := internal.App{
 		Storage: appData,
 		Version: consts.Version,
+		Input:   injector,
+		Events:  source,
 	}
 
+	a.ApplyBackupSettings(appData.GetData().Settings)
 	a.SetupHotkeys()
 
 	app := application.New(application.Options{
@@ -61,11 +70,12 @@ func main() {
 		},
 	})
 
-	createMainWindow(app)
+	createMainWindow(app, &a)
+	a.WatchStorage()
 	app.Run()
 }
 
-func createMainWindow(app *application.App) {
+func createMainWindow(app *application.App, a *internal.App) {
 	mainWindowStartState := application.WindowStateNormal
 	if !consts.IsProduction {
 		mainWindowStartState = application.WindowStateMinimised
@@ -82,6 +92,10 @@ func createMainWindow(app *application.App) {
 		Frameless:  true,
 	})
 
+	w.OnWindowEvent(events.Common.WindowRuntimeReady, func(_ *application.WindowEvent) {
+		a.EmitStartupWarnings()
+	})
+
 	w.RegisterHook(events.Common.WindowClosing, func(event *application.WindowEvent) {
 		app.Quit()
 	})
@@ -99,6 +113,10 @@ func createMainWindow(app *application.App) {
 
 	tray.SetMenu(trayMenu)
 
+	a.Storage.Subscribe(func(data types.AppData) {
+		app.EmitEvent("app_data_changed", data)
+	})
+
 	tray.OnClick(func() {
 		w.UnMinimise()
 		w.Show()